@@ -9,57 +9,102 @@ import (
 	"github.com/ungame/timetrack/app/repository"
 	"github.com/ungame/timetrack/app/router"
 	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/config"
 	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/httpext"
 	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
 	"log"
 	"net/http"
+	"os"
 	"time"
 )
 
-var (
-	port int
-	lite bool
-)
+var cfg *config.Config
 
 func init() {
-	flag.IntVar(&port, "p", 15555, "set port")
-	flag.BoolVar(&lite, "l", true, "set true to run lite version")
-	flag.Parse()
+	var err error
+	cfg, err = config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalln("invalid config:", err.Error())
+	}
 }
 
 func Run() {
-	var conn *sql.DB
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Panicln("invalid timezone:", err.Error())
+	}
+
+	billingRounding, err := timeext.ParseRounding(cfg.Rounding)
+	if err != nil {
+		log.Panicln("invalid rounding:", err.Error())
+	}
+
+	var (
+		conn    *sql.DB
+		dialect = cfg.Database.Dialect
+		clock   = timeext.NewSystemClock()
+	)
 
-	if lite {
-		conn = db.Lite(db.DefaultFileStorage(), db.NewMigration(db.GetSqliteSeed()))
+	if cfg.Lite {
+		conn = db.Lite(db.NewFileStorage(cfg.Database.Path), db.Migrations(db.SQLite), db.NewSearchMigration())
+		dialect = db.SQLite
 	} else {
-		conn = db.New()
+		conn = db.New(cfg.Database)
 	}
 
 	defer ioext.Close(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.Timeout)
 	defer cancel()
 
 	if err := conn.PingContext(ctx); err != nil {
 		log.Panicln("unable to ping database:", err.Error())
 	}
 
+	if !cfg.Lite && cfg.Migrate {
+		db.Migrations(dialect).Up(conn)
+	}
+
 	var (
-		categoriesRepository = repository.NewCategoriesRepository(conn)
+		usersRepository      = repository.NewUsersRepository(conn, dialect)
+		usersService         = service.NewUsersService(usersRepository)
+		usersHandler         = handlers.NewUsersHandler(usersService)
+		tokensRepository     = repository.NewTokensRepository(conn, dialect)
+		tokensService        = service.NewTokensService(tokensRepository)
+		tokensHandler        = handlers.NewTokensHandler(tokensService)
+		healthHandler        = handlers.NewHealthHandler(conn, cfg.Database.Timeout)
+		categoriesRepository = repository.NewCategoriesRepository(conn, dialect)
 		categoriesService    = service.NewCategoriesService(categoriesRepository)
 		categoriesHandler    = handlers.NewCategoriesHandler(categoriesService)
-		activitiesRepository = repository.NewActivitiesRepository(conn)
+		clientsRepository    = repository.NewClientsRepository(conn, dialect)
+		clientsService       = service.NewClientsService(clientsRepository)
+		clientsHandler       = handlers.NewClientsHandler(clientsService)
+		projectsRepository   = repository.NewProjectsRepository(conn, dialect)
+		projectsService      = service.NewProjectsService(clientsService, projectsRepository)
+		projectsHandler      = handlers.NewProjectsHandler(projectsService)
+		activitiesRepository = repository.NewActivitiesRepository(conn, dialect)
 		activitiesObserver   = observer.New("activities")
-		activitiesService    = service.NewActivitiesService(categoriesService, activitiesRepository, activitiesObserver)
+		activitiesService    = service.NewActivitiesService(categoriesService, projectsService, activitiesRepository, activitiesObserver, location, clock)
 		activitiesHandler    = handlers.NewActivitiesHandler(activitiesService)
+		reportsRepository    = repository.NewReportsRepository(conn, dialect)
+		reportsService       = service.NewReportsService(reportsRepository, location, billingRounding, clock)
+		reportsHandler       = handlers.NewReportsHandler(reportsService)
+		calendarHandler      = handlers.NewCalendarHandler(activitiesService, categoriesService)
+		searchRepository     = repository.NewSearchRepository(conn, dialect)
+		searchService        = service.NewSearchService(searchRepository, location, clock)
+		searchHandler        = handlers.NewSearchHandler(searchService)
 	)
 
 	defer activitiesRepository.Close()
 
-	r := router.New(categoriesHandler, activitiesHandler)
+	r := router.New(cfg.Auth, usersService, tokensService, healthHandler, usersHandler, tokensHandler, categoriesHandler, clientsHandler, projectsHandler, activitiesHandler, reportsHandler, calendarHandler, searchHandler)
+
+	if cfg.Auth.Enabled {
+		log.Printf("API tokens required, except on %s\n", cfg.Auth.Exempt.String())
+	}
 
-	log.Printf("Listening http://localhost:%d\n\n", port)
-	log.Fatalln(http.ListenAndServe(httpext.Port(port).Addr(), r.WithCors()))
+	log.Printf("Listening http://localhost:%d\n\n", cfg.Port)
+	log.Fatalln(http.ListenAndServe(httpext.Port(cfg.Port).Addr(), r.WithCors(cfg.Cors)))
 }
@@ -4,44 +4,66 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/ungame/timetrack/httpext"
 	"github.com/ungame/timetrack/ioext"
 	"github.com/ungame/timetrack/timeext"
 	"github.com/ungame/timetrack/types"
 	"io"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"os"
+	"strings"
+	"time"
 )
 
 func (c *CommandLine) PrintActivity(a *types.Activity) {
 
-	category := c.GetCategory(a.CategoryID)
-
 	fmt.Println("-- Activity")
 	fmt.Println("     ID:         ", a.ID)
-	fmt.Printf("     Category:    %s (ID=%d)\n", category.Name, a.CategoryID)
+	if category := c.GetCategory(a.CategoryID); category != nil {
+		fmt.Printf("     Category:    %s (ID=%d)\n", category.Name, a.CategoryID)
+	} else {
+		fmt.Println("     Category ID:", a.CategoryID)
+	}
+	if a.ProjectID != nil {
+		if project := c.GetProject(*a.ProjectID); project != nil {
+			fmt.Printf("     Project:     %s (ID=%d)\n", project.Name, *a.ProjectID)
+		}
+	}
 	fmt.Println("     Description:", a.Description)
 	fmt.Println("     Status:     ", a.Status)
+	if a.Billable != nil && *a.Billable {
+		rate := "category rate"
+		if a.HourlyRate != nil {
+			rate = formatAmount(*a.HourlyRate) + "/h"
+		}
+		fmt.Println("     Billable:   ", rate)
+	}
+	if len(a.Tags) > 0 {
+		fmt.Println("     Tags:       ", "#"+strings.Join(a.Tags, " #"))
+	}
 	if a.StartedAt != nil {
-		fmt.Println("     Started:    ", a.StartedAt.Local().Format(timeext.DateTimeFormat))
+		fmt.Println("     Started:    ", a.StartedAt.In(c.location).Format(timeext.DateTimeFormat))
 	}
 	if a.UpdatedAt != nil {
-		fmt.Println("     Updated:    ", a.UpdatedAt.Local().Format(timeext.DateTimeFormat))
+		fmt.Println("     Updated:    ", a.UpdatedAt.In(c.location).Format(timeext.DateTimeFormat))
 	}
 	if a.FinishedAt != nil {
-		fmt.Println("     Finished:   ", a.FinishedAt.Local().Format(timeext.DateTimeFormat))
-		fmt.Println("     Duration:   ", a.FinishedAt.Sub(*a.StartedAt).String())
+		fmt.Println("     Finished:   ", a.FinishedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if len(a.Intervals) > 1 {
+		fmt.Println("     Intervals:  ", len(a.Intervals))
 	}
+	fmt.Println("     Duration:   ", (time.Duration(a.Duration) * time.Second).String())
 	fmt.Println("")
 }
 
-func (c *CommandLine) StartActivity(description string, category int64) {
+// StartActivity starts the input activity tagged with the #hashtags of its
+// description.
+func (c *CommandLine) StartActivity(input *types.Activity) {
 
-	input := &types.Activity{
-		CategoryID:  category,
-		Description: description,
-	}
+	input.Tags = types.HashtagsOf(input.Description)
 
 	payload, err := json.Marshal(input)
 	if err != nil {
@@ -57,7 +79,7 @@ func (c *CommandLine) StartActivity(description string, category int64) {
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -71,6 +93,11 @@ func (c *CommandLine) StartActivity(description string, category int64) {
 		return
 	}
 
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
 	var activity types.Activity
 	err = json.Unmarshal(body, &activity)
 	if err != nil {
@@ -81,44 +108,127 @@ func (c *CommandLine) StartActivity(description string, category int64) {
 	c.PrintActivity(&activity)
 }
 
-func (c *CommandLine) ListActivities() {
+// AddActivity records the input activity, finished between its start and
+// finish times, tagged with the #hashtags of its description.
+func (c *CommandLine) AddActivity(input *types.Activity) {
 
-	uri := fmt.Sprintf("%s/activities", c.baseURL)
+	input.Tags = types.HashtagsOf(input.Description)
 
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	payload, err := json.Marshal(input)
 	if err != nil {
 		log.Println(err.Error())
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	uri := fmt.Sprintf("%s/activities/_/manual", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(payload))
 	if err != nil {
-		fmt.Println(err.Error())
+		log.Println(err.Error())
 		return
 	}
 
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
 	if res != nil && res.Body != nil {
 		defer ioext.Close(res.Body)
 	}
-
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
 
-	var activities []*types.Activity
-	err = json.Unmarshal(body, &activities)
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var activity types.Activity
+	err = json.Unmarshal(body, &activity)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
 
-	for _, activity := range activities {
-		c.PrintActivity(activity)
+	c.PrintActivity(&activity)
+}
+
+// ListActivities prints the activities of the filter a page at a time,
+// following the next page links of the server.
+func (c *CommandLine) ListActivities(filter *types.ActivityFilter) {
+
+	uri, err := url.Parse(fmt.Sprintf("%s/activities", c.baseURL))
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	query := url.Values{}
+	setActivityFilter(query, filter)
+	query.Set("page_size", fmt.Sprint(listPageSize))
+	uri.RawQuery = query.Encode()
+
+	for uri != nil {
+		var activities []*types.Activity
+		activities, uri, err = c.getActivitiesPage(uri)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		for _, activity := range activities {
+			c.PrintActivity(activity)
+		}
 	}
 }
 
+// listPageSize is the number of activities ListActivities reads at a time.
+const listPageSize = 100
+
+// getActivitiesPage returns the page of activities of uri and the uri of the
+// next page, or nil after the last one.
+func (c *CommandLine) getActivitiesPage(uri *url.URL) ([]*types.Activity, *url.URL, error) {
+
+	req, err := http.NewRequest(http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ioext.Close(res.Body)
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, nil, fmt.Errorf("[ERROR] %w", problemOf(res, body))
+	}
+
+	var activities []*types.Activity
+	if err = json.Unmarshal(body, &activities); err != nil {
+		return nil, nil, err
+	}
+
+	link := httpext.NextLink(res.Header)
+	if link == "" {
+		return activities, nil, nil
+	}
+	next, err := uri.Parse(link)
+	if err != nil {
+		return activities, nil, err
+	}
+	return activities, next, nil
+}
+
 func (c *CommandLine) FilterActivities(filter *types.PeriodFilter) {
 
 	uri := fmt.Sprintf("%s/activities/_/filter", c.baseURL)
@@ -131,11 +241,21 @@ func (c *CommandLine) FilterActivities(filter *types.PeriodFilter) {
 
 	query := url.Values{}
 	query.Set("period", filter.PeriodName)
+	if filter.WeekStart != "" {
+		query.Set("week_start", filter.WeekStart)
+	}
+	if filter.From != "" {
+		query.Set("from", filter.From)
+	}
+	if filter.To != "" {
+		query.Set("to", filter.To)
+	}
+	setActivityFilter(query, &filter.ActivityFilter)
 	query.Set("order", filter.OrderBy)
 	query.Set("limit", fmt.Sprint(filter.Limit))
 	req.URL.RawQuery = query.Encode()
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -143,43 +263,45 @@ func (c *CommandLine) FilterActivities(filter *types.PeriodFilter) {
 
 	if res != nil && res.Body != nil {
 		defer ioext.Close(res.Body)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
 
-		if res.StatusCode >= http.StatusBadRequest {
-			d, err := httputil.DumpRequest(req, true)
-			if err != nil {
-				log.Panicln(err)
-			}
-			fmt.Println(string(d))
-			d, err = httputil.DumpResponse(res, true)
-			if err != nil {
-				log.Panicln(err)
-			}
-			fmt.Println(string(d))
-			return
-		}
-
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
-		}
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
 
-		var activities []*types.Activity
-		err = json.Unmarshal(body, &activities)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
-		}
+	var activities []*types.Activity
+	err = json.Unmarshal(body, &activities)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
 
-		for _, activity := range activities {
-			c.PrintActivity(activity)
-		}
+	for _, activity := range activities {
+		c.PrintActivity(activity)
 	}
 }
 
 func (c *CommandLine) FinishActivity(id int64) {
+	c.changeActivityStatus(id, "finish")
+}
 
-	uri := fmt.Sprintf("%s/activities/%d/finish", c.baseURL, id)
+func (c *CommandLine) PauseActivity(id int64) {
+	c.changeActivityStatus(id, "pause")
+}
+
+func (c *CommandLine) ResumeActivity(id int64) {
+	c.changeActivityStatus(id, "resume")
+}
+
+func (c *CommandLine) changeActivityStatus(id int64, action string) {
+
+	uri := fmt.Sprintf("%s/activities/%d/%s", c.baseURL, id, action)
 
 	req, err := http.NewRequest(http.MethodPut, uri, nil)
 	if err != nil {
@@ -187,7 +309,7 @@ func (c *CommandLine) FinishActivity(id int64) {
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -201,6 +323,11 @@ func (c *CommandLine) FinishActivity(id int64) {
 		return
 	}
 
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
 	var activity types.Activity
 	err = json.Unmarshal(body, &activity)
 	if err != nil {
@@ -221,7 +348,7 @@ func (c *CommandLine) DeleteActivity(id int64) {
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -231,20 +358,82 @@ func (c *CommandLine) DeleteActivity(id int64) {
 	}
 
 	if res.StatusCode != http.StatusNoContent {
-		if res.StatusCode >= http.StatusBadRequest {
-			d, err := httputil.DumpRequest(req, true)
-			if err != nil {
-				log.Panicln(err)
-			}
-			fmt.Println(string(d))
-			d, err = httputil.DumpResponse(res, true)
-			if err != nil {
-				log.Panicln(err)
-			}
-			fmt.Println(string(d))
+		body, _ := io.ReadAll(res.Body)
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	fmt.Println("Activity deleted:", res.Header.Get("Entity"))
+}
+
+// setActivityFilter sets the tag, project_id and client_id query parameters
+// of the filter that are not empty.
+func setActivityFilter(query url.Values, filter *types.ActivityFilter) {
+	if filter.Tag != "" {
+		query.Set("tag", filter.Tag)
+	}
+	if filter.ProjectID != 0 {
+		query.Set("project_id", fmt.Sprint(filter.ProjectID))
+	}
+	if filter.ClientID != 0 {
+		query.Set("client_id", fmt.Sprint(filter.ClientID))
+	}
+}
+
+// ExportActivities writes the filtered activities as CSV to the output file,
+// or to the standard output when it is empty.
+func (c *CommandLine) ExportActivities(filter *types.PeriodFilter, output string) {
+
+	uri := fmt.Sprintf("%s/activities/export.csv", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"period":     filter.PeriodName,
+		"week_start": filter.WeekStart,
+		"from":       filter.From,
+		"to":         filter.To,
+		"order":      filter.OrderBy,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", fmt.Sprint(filter.Limit))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(res.Body)
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Println("[ERROR]", err.Error())
 			return
 		}
+		defer ioext.Close(file)
+		out = file
 	}
 
-	fmt.Println("Activity deleted:", res.Header.Get("Entity"))
-}
\ No newline at end of file
+	if _, err = io.Copy(out, res.Body); err != nil {
+		fmt.Println("[ERROR]", err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"text/tabwriter"
+)
+
+// Billing prints the billable hours and amounts of the filter. The rounding
+// rule is checked with the same parser as the server before it is sent.
+func (c *CommandLine) Billing(filter *types.BillingFilter) {
+
+	if filter.Rounding != "" {
+		rounding, err := timeext.ParseRounding(filter.Rounding)
+		if err != nil {
+			fmt.Println("[ERROR] rounding:", err.Error())
+			return
+		}
+		filter.Rounding = rounding.String()
+	}
+
+	uri := fmt.Sprintf("%s/reports/billing", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	query := reportQueryOf(&filter.PeriodFilter)
+	if filter.Rounding != "" {
+		query.Set("rounding", filter.Rounding)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var billing types.Billing
+	err = json.Unmarshal(body, &billing)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	c.PrintBilling(&billing)
+}
+
+func (c *CommandLine) PrintBilling(b *types.Billing) {
+	fmt.Println("-- Billing")
+	if b.From != nil && b.To != nil {
+		fmt.Println("     From:    ", b.From.In(c.location).Format(timeext.DateTimeFormat))
+		fmt.Println("     To:      ", b.To.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("     Rounding:", b.Rounding)
+	fmt.Println("")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CATEGORY\tCOUNT\tDURATION\tHOURS\tAMOUNT\t")
+	for _, category := range b.Categories {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%s\t\n",
+			category.Category, category.Count, timeext.FormatHoursMinutes(seconds(category.Duration)), category.Hours, formatAmount(category.Amount))
+	}
+	_, _ = fmt.Fprintf(w, "TOTAL\t%d\t%s\t%.2f\t%s\t\n",
+		b.Count, timeext.FormatHoursMinutes(seconds(b.Duration)), b.Hours, formatAmount(b.Amount))
+	_ = w.Flush()
+}
+
+// formatAmount formats an amount in cents with two decimals.
+func formatAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -10,16 +10,19 @@ import (
 	"net/http"
 )
 
-func PrintCategory(c *types.Category) {
+func (c *CommandLine) PrintCategory(category *types.Category) {
 	fmt.Println("-- Category")
-	fmt.Println("     ID:         ", c.ID)
-	fmt.Println("     Name:       ", c.Name)
-	fmt.Println("     Description:", c.Description)
-	if c.CreatedAt != nil {
-		fmt.Println("     Created:", c.CreatedAt.Local().Format(timeext.DateTimeFormat))
+	fmt.Println("     ID:         ", category.ID)
+	fmt.Println("     Name:       ", category.Name)
+	fmt.Println("     Description:", category.Description)
+	if category.HourlyRate > 0 {
+		fmt.Println("     Rate:       ", formatAmount(category.HourlyRate)+"/h")
 	}
-	if c.UpdatedAt != nil {
-		fmt.Println("     Updated:", c.UpdatedAt.Local().Format(timeext.DateTimeFormat))
+	if category.CreatedAt != nil {
+		fmt.Println("     Created:", category.CreatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if category.UpdatedAt != nil {
+		fmt.Println("     Updated:", category.UpdatedAt.In(c.location).Format(timeext.DateTimeFormat))
 	}
 	fmt.Println("")
 }
@@ -33,7 +36,7 @@ func (c *CommandLine) GetCategory(id int64) (category *types.Category) {
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -49,6 +52,11 @@ func (c *CommandLine) GetCategory(id int64) (category *types.Category) {
 		return
 	}
 
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR] category", id, problemOf(res, body))
+		return
+	}
+
 	err = json.Unmarshal(body, &category)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -67,7 +75,7 @@ func (c *CommandLine) ListCategories() {
 		return
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
@@ -83,6 +91,11 @@ func (c *CommandLine) ListCategories() {
 		return
 	}
 
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
 	var categories []*types.Category
 	err = json.Unmarshal(body, &categories)
 	if err != nil {
@@ -91,6 +104,6 @@ func (c *CommandLine) ListCategories() {
 	}
 
 	for _, category := range categories {
-		PrintCategory(category)
+		c.PrintCategory(category)
 	}
 }
@@ -1,23 +1,69 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/timeext"
 	"github.com/ungame/timetrack/types"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 type CommandLine struct {
-	baseURL string
+	baseURL  string
+	location *time.Location
+	user     string
+	token    string
 }
 
-func New(baseURL string) *CommandLine {
-	return &CommandLine{baseURL: baseURL}
+// New creates the command line client. Times are read and printed in
+// location, which is also sent to the server to resolve periods. The
+// activities are the ones of user, or of the default user if it is empty,
+// and token authenticates the requests to servers that require API tokens.
+func New(baseURL string, location *time.Location, user, token string) *CommandLine {
+	return &CommandLine{baseURL: baseURL, location: location, user: user, token: token}
+}
+
+// do sends the request with the timezone, the user and the token of the
+// command line.
+func (c *CommandLine) do(req *http.Request) (*http.Response, error) {
+	if c.location != time.Local {
+		req.Header.Set(httpext.HeaderTimezone, c.location.String())
+	}
+	if c.user != "" {
+		req.Header.Set(httpext.HeaderUser, c.user)
+	}
+	if c.token != "" {
+		req.Header.Set(httpext.HeaderAuthorization, "Bearer "+c.token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// problemOf returns the error of the problem+json body of the response, with
+// its code and detail, or the status of the response when the body is not a
+// problem.
+func problemOf(res *http.Response, body []byte) error {
+	var problem types.Problem
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Code == "" {
+		return errors.New(res.Status)
+	}
+	if problem.Detail == "" {
+		return fmt.Errorf("%s: %s", problem.Code, problem.Title)
+	}
+	return fmt.Errorf("%s: %s", problem.Code, problem.Detail)
 }
 
 func (c *CommandLine) Run() {
 
-	if len(os.Args) < 2 {
+	args := flag.Args()
+
+	if len(args) < 1 {
 		c.Usage()
 		os.Exit(0)
 	}
@@ -27,48 +73,200 @@ func (c *CommandLine) Run() {
 		description string
 		category    int
 		period      string
+		from        string
+		to          string
+		weekStart   string
+		tag         string
+		project     int64
+		client      int64
+		billable    bool
+		rate        int64
+		rounding    string
+		search      string
+		output      string
+		file        string
+		dryRun      bool
 		order       string
 		limit       int
 		activityID  int64
+		startedAt   string
+		finishedAt  string
+		tokenName   string
+		tokenScope  string
+		tokenID     int64
 	)
 
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	listCmd.StringVar(&listName, "n", "", "name list")
 	listCmd.StringVar(&period, "p", "", "list by period")
+	listCmd.StringVar(&from, "f", "", "list from date")
+	listCmd.StringVar(&to, "t", "", "list to date")
+	listCmd.StringVar(&weekStart, "w", "", "first day of the week")
+	listCmd.StringVar(&tag, "tag", "", "list by tag")
+	listCmd.Int64Var(&project, "project", 0, "list by project id")
+	listCmd.Int64Var(&client, "client", 0, "list by client id")
 	listCmd.StringVar(&order, "o", "", "order items")
 	listCmd.IntVar(&limit, "l", 0, "limit items")
 
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	exportCmd.StringVar(&period, "p", "", "export by period")
+	exportCmd.StringVar(&from, "f", "", "export from date")
+	exportCmd.StringVar(&to, "t", "", "export to date")
+	exportCmd.StringVar(&weekStart, "w", "", "first day of the week")
+	exportCmd.StringVar(&order, "o", "", "order items")
+	exportCmd.IntVar(&limit, "l", 0, "limit items")
+	exportCmd.StringVar(&output, "out", "", "output file")
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	importCmd.StringVar(&file, "file", "", "file to import")
+	importCmd.BoolVar(&dryRun, "dry-run", false, "validate without importing")
+
+	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+	reportCmd.StringVar(&period, "p", "", "report by period")
+	reportCmd.StringVar(&from, "f", "", "report from date")
+	reportCmd.StringVar(&to, "t", "", "report to date")
+	reportCmd.StringVar(&weekStart, "w", "", "first day of the week")
+	reportCmd.StringVar(&tag, "tag", "", "report by tag")
+	reportCmd.Int64Var(&project, "project", 0, "report by project id")
+	reportCmd.Int64Var(&client, "client", 0, "report by client id")
+
+	billingCmd := flag.NewFlagSet("billing", flag.ExitOnError)
+	billingCmd.StringVar(&period, "p", "", "bill by period")
+	billingCmd.StringVar(&from, "f", "", "bill from date")
+	billingCmd.StringVar(&to, "t", "", "bill to date")
+	billingCmd.StringVar(&weekStart, "w", "", "first day of the week")
+	billingCmd.StringVar(&tag, "tag", "", "bill by tag")
+	billingCmd.Int64Var(&project, "project", 0, "bill by project id")
+	billingCmd.Int64Var(&client, "client", 0, "bill by client id")
+	billingCmd.StringVar(&rounding, "r", "", "round each activity, e.g. up:15m")
+
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	searchCmd.StringVar(&search, "q", "", "words to search")
+	searchCmd.StringVar(&period, "p", "", "search by period")
+	searchCmd.StringVar(&from, "f", "", "search from date")
+	searchCmd.StringVar(&to, "t", "", "search to date")
+	searchCmd.StringVar(&weekStart, "w", "", "first day of the week")
+	searchCmd.StringVar(&tag, "tag", "", "search by tag")
+	searchCmd.Int64Var(&project, "project", 0, "search by project id")
+	searchCmd.Int64Var(&client, "client", 0, "search by client id")
+	searchCmd.IntVar(&limit, "l", 0, "limit items")
+
 	startActivityCmd := flag.NewFlagSet("start", flag.ExitOnError)
 	startActivityCmd.StringVar(&description, "d", "", "set description")
 	startActivityCmd.IntVar(&category, "c", 0, "set category id")
+	startActivityCmd.Int64Var(&project, "project", 0, "set project id")
+	startActivityCmd.BoolVar(&billable, "billable", false, "set billable")
+	startActivityCmd.Int64Var(&rate, "rate", -1, "set hourly rate in cents")
+
+	addActivityCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	addActivityCmd.StringVar(&description, "d", "", "set description")
+	addActivityCmd.IntVar(&category, "c", 0, "set category id")
+	addActivityCmd.Int64Var(&project, "project", 0, "set project id")
+	addActivityCmd.BoolVar(&billable, "billable", false, "set billable")
+	addActivityCmd.Int64Var(&rate, "rate", -1, "set hourly rate in cents")
+	addActivityCmd.StringVar(&startedAt, "s", "", "set start time")
+	addActivityCmd.StringVar(&finishedAt, "e", "", "set end time")
 
 	finishActivityCmd := flag.NewFlagSet("finish", flag.ExitOnError)
 	finishActivityCmd.Int64Var(&activityID, "id", 0, "activity id to finish")
 
+	pauseActivityCmd := flag.NewFlagSet("pause", flag.ExitOnError)
+	pauseActivityCmd.Int64Var(&activityID, "id", 0, "activity id to pause")
+
+	resumeActivityCmd := flag.NewFlagSet("resume", flag.ExitOnError)
+	resumeActivityCmd.Int64Var(&activityID, "id", 0, "activity id to resume")
+
 	deleteActivityCmd := flag.NewFlagSet("delete", flag.ExitOnError)
 	deleteActivityCmd.Int64Var(&activityID, "id", 0, "activity id to delete")
 
-	switch os.Args[1] {
+	createTokenCmd := flag.NewFlagSet("token", flag.ExitOnError)
+	createTokenCmd.StringVar(&tokenName, "name", "", "set token name")
+	createTokenCmd.StringVar(&tokenScope, "scope", types.ScopeRead, "set token scope, read or write")
+
+	revokeTokenCmd := flag.NewFlagSet("revoke", flag.ExitOnError)
+	revokeTokenCmd.Int64Var(&tokenID, "id", 0, "token id to revoke")
+
+	switch args[0] {
 	case "list":
-		if err := listCmd.Parse(os.Args[2:]); err != nil {
+		if err := listCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "export":
+		if err := exportCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "import":
+		if err := importCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "report":
+		if err := reportCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "billing":
+		if err := billingCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "search":
+		if err := searchCmd.Parse(args[1:]); err != nil {
 			c.Usage()
 			return
 		}
 
 	case "start":
-		if err := startActivityCmd.Parse(os.Args[2:]); err != nil {
+		if err := startActivityCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "add":
+		if err := addActivityCmd.Parse(args[1:]); err != nil {
 			c.Usage()
 			return
 		}
 
 	case "finish":
-		if err := finishActivityCmd.Parse(os.Args[2:]); err != nil {
+		if err := finishActivityCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "pause":
+		if err := pauseActivityCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "resume":
+		if err := resumeActivityCmd.Parse(args[1:]); err != nil {
 			c.Usage()
 			return
 		}
 
 	case "delete":
-		if err := deleteActivityCmd.Parse(os.Args[2:]); err != nil {
+		if err := deleteActivityCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "token":
+		if err := createTokenCmd.Parse(args[1:]); err != nil {
+			c.Usage()
+			return
+		}
+
+	case "revoke":
+		if err := revokeTokenCmd.Parse(args[1:]); err != nil {
 			c.Usage()
 			return
 		}
@@ -78,8 +276,14 @@ func (c *CommandLine) Run() {
 		os.Exit(0)
 	}
 
+	activityFilter := types.ActivityFilter{
+		Tag:       tag,
+		ProjectID: project,
+		ClientID:  client,
+	}
+
 	if listCmd.Parsed() {
-		hasFilter := period != "" || order != "" || limit > 0
+		hasFilter := period != "" || from != "" || to != "" || order != "" || limit > 0
 		if hasFilter {
 
 			if limit == 0 {
@@ -87,57 +291,227 @@ func (c *CommandLine) Run() {
 			}
 
 			c.List(listName, &types.PeriodFilter{
-				PeriodName: period,
-				OrderBy:    order,
-				Limit:      limit,
-			})
+				PeriodName:     period,
+				From:           from,
+				To:             to,
+				WeekStart:      weekStart,
+				OrderBy:        order,
+				Limit:          limit,
+				ActivityFilter: activityFilter,
+			}, &activityFilter)
 		} else {
-			c.List(listName, nil)
+			c.List(listName, nil, &activityFilter)
 		}
 	}
 
+	if exportCmd.Parsed() {
+		c.ExportActivities(&types.PeriodFilter{
+			PeriodName: period,
+			From:       from,
+			To:         to,
+			WeekStart:  weekStart,
+			OrderBy:    order,
+			Limit:      limit,
+		}, output)
+	}
+
+	if importCmd.Parsed() {
+		if file == "" {
+			c.Usage()
+			return
+		}
+		c.ImportActivities(file, dryRun)
+	}
+
+	if reportCmd.Parsed() {
+		c.Report(&types.PeriodFilter{
+			PeriodName:     period,
+			From:           from,
+			To:             to,
+			WeekStart:      weekStart,
+			ActivityFilter: activityFilter,
+		})
+	}
+
+	if billingCmd.Parsed() {
+		c.Billing(&types.BillingFilter{
+			PeriodFilter: types.PeriodFilter{
+				PeriodName:     period,
+				From:           from,
+				To:             to,
+				WeekStart:      weekStart,
+				ActivityFilter: activityFilter,
+			},
+			Rounding: rounding,
+		})
+	}
+
+	if searchCmd.Parsed() {
+		if strings.TrimSpace(search) == "" {
+			c.Usage()
+			return
+		}
+		c.Search(&types.SearchFilter{
+			Query: search,
+			PeriodFilter: types.PeriodFilter{
+				PeriodName:     period,
+				From:           from,
+				To:             to,
+				WeekStart:      weekStart,
+				Limit:          limit,
+				ActivityFilter: activityFilter,
+			},
+		})
+	}
+
+	input := &types.Activity{
+		CategoryID:  int64(category),
+		Description: description,
+	}
+	if project != 0 {
+		input.ProjectID = pointer.New(project)
+	}
+	if billable {
+		input.Billable = pointer.New(true)
+	}
+	if rate >= 0 {
+		input.HourlyRate = pointer.New(rate)
+	}
+
 	if startActivityCmd.Parsed() {
-		c.StartActivity(description, int64(category))
+		c.StartActivity(input)
+	}
+
+	if addActivityCmd.Parsed() {
+		start, err := timeext.Parse(timeext.NewSystemClock(), startedAt, c.location)
+		if err != nil {
+			fmt.Println("[ERROR] start:", err.Error())
+			return
+		}
+		end, err := timeext.Parse(timeext.NewSystemClock(), finishedAt, c.location)
+		if err != nil {
+			fmt.Println("[ERROR] end:", err.Error())
+			return
+		}
+		input.StartedAt, input.FinishedAt = &start, &end
+		c.AddActivity(input)
 	}
 
 	if finishActivityCmd.Parsed() {
 		c.FinishActivity(activityID)
 	}
 
+	if pauseActivityCmd.Parsed() {
+		c.PauseActivity(activityID)
+	}
+
+	if resumeActivityCmd.Parsed() {
+		c.ResumeActivity(activityID)
+	}
+
 	if deleteActivityCmd.Parsed() {
 		c.DeleteActivity(activityID)
 	}
+
+	if createTokenCmd.Parsed() {
+		c.CreateToken(&types.Token{Name: tokenName, Scope: tokenScope})
+	}
+
+	if revokeTokenCmd.Parsed() {
+		c.RevokeToken(tokenID)
+	}
 }
 
 func (c *CommandLine) Usage() {
 	fmt.Println("-- Usage:")
 	fmt.Println(" ")
-	fmt.Println("     list  -n LIST_NAME -p PERIOD -o ORDER -l LIMIT")
-	fmt.Println("              LIST_NAME (required): [categories, activities]")
-	fmt.Println("              PERIOD (optional):    [today,yesterday,weekly,monthly]")
+	fmt.Println("     list  -n LIST_NAME -p PERIOD -w WEEK_START -f FROM -t TO -o ORDER -l LIMIT -tag TAG -project PROJECT_ID -client CLIENT_ID")
+	fmt.Println("              LIST_NAME (required): [categories, clients, projects, activities, users, tokens]")
+	fmt.Println("              PERIOD (optional):    [today,yesterday,weekly,monthly,")
+	fmt.Println("                                     this_week,last_week,this_month,last_month,")
+	fmt.Println("                                     this_quarter,last_quarter,this_year,last_year]")
+	fmt.Println("              WEEK_START (optional): [monday,sunday,...], defaults to monday")
+	fmt.Println("              FROM, TO (optional):  [\"2006-01-02\", \"2006-01-02 15:04\", RFC 3339], not with PERIOD")
 	fmt.Println("              ORDER  (optional):    [asc,desc]")
 	fmt.Println("              LIMIT  (optional):    must be a number greater than 0")
+	fmt.Println("              TAG    (optional):    only activities with the tag, e.g. oncall")
+	fmt.Println("              PROJECT_ID (optional): only activities of the project")
+	fmt.Println("              CLIENT_ID  (optional): only activities of the projects of the client, or projects of the client")
+	fmt.Println("")
+	fmt.Println("     export -p PERIOD -w WEEK_START -f FROM -t TO -o ORDER -l LIMIT -out FILE")
+	fmt.Println("              activities as CSV, same filters as list, defaults to today")
+	fmt.Println("              FILE (optional): defaults to the standard output")
+	fmt.Println("")
+	fmt.Println("     import -file FILE -dry-run")
+	fmt.Println("              activities from a CSV (.csv) or JSON lines file, see the export columns")
+	fmt.Println("              FILE (required):    rows name a category by id or name, and a start and end time")
+	fmt.Println("              -dry-run (optional): validate the rows without importing them")
+	fmt.Println("")
+	fmt.Println("     report -p PERIOD -w WEEK_START -f FROM -t TO -tag TAG -project PROJECT_ID -client CLIENT_ID")
+	fmt.Println("              time per category, tag and day, same filters as list, defaults to today")
+	fmt.Println("")
+	fmt.Println("     billing -p PERIOD -w WEEK_START -f FROM -t TO -tag TAG -project PROJECT_ID -client CLIENT_ID -r ROUNDING")
+	fmt.Println("              billable hours and amounts per category, same filters as report")
+	fmt.Println("              ROUNDING (optional): [none, up:15m, down:15m, nearest:6m, ...], defaults to the server rule")
+	fmt.Println("")
+	fmt.Println("     search -q QUERY -p PERIOD -w WEEK_START -f FROM -t TO -l LIMIT -tag TAG -project PROJECT_ID -client CLIENT_ID")
+	fmt.Println("              activities by words of their description, tags or category, best matches first")
+	fmt.Println("              QUERY (required): e.g. \"incident postmortem\"")
+	fmt.Println("              PERIOD, FROM, TO (optional): as in list, defaults to all time")
+	fmt.Println("              LIMIT (optional): defaults to 20, at most 100")
 	fmt.Println("")
-	fmt.Println("     start -d DESCRIPTION -c CATEGORY_ID")
-	fmt.Println("              DESCRIPTION (optional)")
+	fmt.Println("     start -d DESCRIPTION -c CATEGORY_ID -project PROJECT_ID -billable -rate RATE")
+	fmt.Println("              DESCRIPTION (optional): #hashtags are added as tags, e.g. \"deploy #release-42\"")
 	fmt.Println("              CATEGORY_ID (required): must be an existing category")
+	fmt.Println("              PROJECT_ID  (optional): must be an existing project")
+	fmt.Println("              -billable   (optional): bill the activity")
+	fmt.Println("              RATE        (optional): hourly rate in cents, defaults to the rate of the category")
+	fmt.Println("")
+	fmt.Println("     add   -d DESCRIPTION -c CATEGORY_ID -project PROJECT_ID -billable -rate RATE -s START -e END")
+	fmt.Println("              DESCRIPTION (optional): #hashtags are added as tags")
+	fmt.Println("              CATEGORY_ID (required): must be an existing category")
+	fmt.Println("              PROJECT_ID  (optional): must be an existing project")
+	fmt.Println("              -billable, RATE (optional): as in start")
+	fmt.Println("              START, END  (required): [\"2006-01-02 15:04\", \"15:04\" (today), RFC 3339]")
 	fmt.Println("")
 	fmt.Println("     finish -id ACTIVITY_ID")
 	fmt.Println("                ACTIVITY_ID (required): must be an existing activity")
 	fmt.Println("")
+	fmt.Println("     pause -id ACTIVITY_ID")
+	fmt.Println("                ACTIVITY_ID (required): must be a started activity")
+	fmt.Println("")
+	fmt.Println("     resume -id ACTIVITY_ID")
+	fmt.Println("                ACTIVITY_ID (required): must be a paused activity")
+	fmt.Println("")
 	fmt.Println("     delete -id ACTIVITY_ID")
 	fmt.Println("                ACTIVITY_ID (required): must be an existing activity")
+	fmt.Println("")
+	fmt.Println("     token -name NAME -scope SCOPE")
+	fmt.Println("                create an API token of the user and print its secret, which can't be read again")
+	fmt.Println("                NAME  (required): e.g. laptop")
+	fmt.Println("                SCOPE (optional): [read, write], defaults to read")
+	fmt.Println("")
+	fmt.Println("     revoke -id TOKEN_ID")
+	fmt.Println("                TOKEN_ID (required): must be a token of the user")
 }
 
-func (c *CommandLine) List(name string, filter *types.PeriodFilter) {
+func (c *CommandLine) List(name string, filter *types.PeriodFilter, activityFilter *types.ActivityFilter) {
 	switch name {
 	case "categories":
 		c.ListCategories()
+	case "clients":
+		c.ListClients()
+	case "projects":
+		c.ListProjects(activityFilter.ClientID)
+	case "users":
+		c.ListUsers()
+	case "tokens":
+		c.ListTokens()
 	case "activities":
 		if filter != nil {
 			c.FilterActivities(filter)
 		} else {
-			c.ListActivities()
+			c.ListActivities(activityFilter)
 		}
 	default:
 		c.Usage()
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+)
+
+func (c *CommandLine) PrintClient(client *types.Client) {
+	fmt.Println("-- Client")
+	fmt.Println("     ID:         ", client.ID)
+	fmt.Println("     Name:       ", client.Name)
+	fmt.Println("     Description:", client.Description)
+	if client.CreatedAt != nil {
+		fmt.Println("     Created:", client.CreatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if client.UpdatedAt != nil {
+		fmt.Println("     Updated:", client.UpdatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("")
+}
+
+func (c *CommandLine) ListClients() {
+	uri := fmt.Sprintf("%s/clients", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var clients []*types.Client
+	err = json.Unmarshal(body, &clients)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	for _, client := range clients {
+		c.PrintClient(client)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// ImportActivities sends the activities of a CSV or JSON lines file, as told
+// by its extension, and prints the outcome of each row.
+func (c *CommandLine) ImportActivities(path string, dryRun bool) {
+	contentType := httpext.MimeNDJSON
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		contentType = httpext.MimeCSV
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Println("[ERROR]", err.Error())
+		return
+	}
+	defer ioext.Close(file)
+
+	uri := fmt.Sprintf("%s/activities/_/import?dry_run=%t", c.baseURL, dryRun)
+
+	req, err := http.NewRequest(http.MethodPost, uri, file)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	req.Header.Set(httpext.HeaderContentType, contentType)
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(res.Body)
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var result types.ImportResult
+	if err = json.NewDecoder(res.Body).Decode(&result); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	c.PrintImportResult(&result)
+}
+
+func (c *CommandLine) PrintImportResult(result *types.ImportResult) {
+	if result.DryRun {
+		fmt.Println("-- Import (dry run)")
+	} else {
+		fmt.Println("-- Import")
+	}
+	fmt.Println("")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ROW\tSTATUS\tID\tERROR\t")
+	for _, row := range result.Rows {
+		id := ""
+		if row.ID > 0 {
+			id = fmt.Sprint(row.ID)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t\n", row.Row, row.Status, id, row.Error)
+	}
+	_ = w.Flush()
+
+	fmt.Println("")
+	fmt.Printf("Imported: %d, Skipped: %d, Failed: %d\n", result.Imported, result.Skipped, result.Failed)
+}
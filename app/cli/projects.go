@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+func (c *CommandLine) PrintProject(project *types.Project) {
+	fmt.Println("-- Project")
+	fmt.Println("     ID:         ", project.ID)
+	fmt.Println("     Name:       ", project.Name)
+	if project.ClientID != 0 {
+		fmt.Println("     Client ID:  ", project.ClientID)
+	}
+	fmt.Println("     Description:", project.Description)
+	if project.CreatedAt != nil {
+		fmt.Println("     Created:", project.CreatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if project.UpdatedAt != nil {
+		fmt.Println("     Updated:", project.UpdatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("")
+}
+
+func (c *CommandLine) GetProject(id int64) (project *types.Project) {
+	uri := fmt.Sprintf("%s/projects/%d", c.baseURL, id)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR] project", id, problemOf(res, body))
+		return
+	}
+
+	err = json.Unmarshal(body, &project)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	return
+}
+
+// ListProjects lists the projects, only the ones of the client unless
+// clientID is zero.
+func (c *CommandLine) ListProjects(clientID int64) {
+	uri := fmt.Sprintf("%s/projects", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if clientID != 0 {
+		req.URL.RawQuery = url.Values{"client_id": {fmt.Sprint(clientID)}}.Encode()
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var projects []*types.Project
+	err = json.Unmarshal(body, &projects)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	for _, project := range projects {
+		c.PrintProject(project)
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func (c *CommandLine) Report(filter *types.PeriodFilter) {
+
+	uri := fmt.Sprintf("%s/reports/summary", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	req.URL.RawQuery = reportQueryOf(filter).Encode()
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var summary types.Summary
+	err = json.Unmarshal(body, &summary)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	c.PrintSummary(&summary)
+}
+
+// reportQueryOf returns the query parameters of the filter that are not
+// empty, as reports read them.
+func reportQueryOf(filter *types.PeriodFilter) url.Values {
+	query := url.Values{}
+	if filter.PeriodName != "" {
+		query.Set("period", filter.PeriodName)
+	}
+	if filter.WeekStart != "" {
+		query.Set("week_start", filter.WeekStart)
+	}
+	if filter.From != "" {
+		query.Set("from", filter.From)
+	}
+	if filter.To != "" {
+		query.Set("to", filter.To)
+	}
+	setActivityFilter(query, &filter.ActivityFilter)
+	return query
+}
+
+func (c *CommandLine) PrintSummary(s *types.Summary) {
+	fmt.Println("-- Summary")
+	if s.From != nil && s.To != nil {
+		fmt.Println("     From:", s.From.In(c.location).Format(timeext.DateTimeFormat))
+		fmt.Println("     To:  ", s.To.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CATEGORY\tCOUNT\tDURATION\tSHARE\t")
+	printCategorySummaries(w, "", s.Categories)
+	_, _ = fmt.Fprintf(w, "TOTAL\t%d\t%s\t\t\n", s.Count, seconds(s.Duration))
+	_ = w.Flush()
+
+	if len(s.Tags) > 0 {
+		fmt.Println("")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TAG\tCOUNT\tDURATION\t")
+		for _, tag := range s.Tags {
+			_, _ = fmt.Fprintf(w, "#%s\t%d\t%s\t\n", tag.Tag, tag.Count, seconds(tag.Duration))
+		}
+		_ = w.Flush()
+	}
+
+	if len(s.Days) == 0 {
+		return
+	}
+
+	fmt.Println("")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DAY\tCATEGORY\tCOUNT\tDURATION\tSHARE\t")
+	for _, day := range s.Days {
+		printCategorySummaries(w, day.Date+"\t", day.Categories)
+		_, _ = fmt.Fprintf(w, "%s\tTOTAL\t%d\t%s\t\t\n", day.Date, day.Count, seconds(day.Duration))
+	}
+	_ = w.Flush()
+}
+
+func printCategorySummaries(w io.Writer, prefix string, categories []*types.CategorySummary) {
+	for _, category := range categories {
+		_, _ = fmt.Fprintf(w, "%s%s\t%d\t%s\t%.1f%%\t\n",
+			prefix, category.Category, category.Count, seconds(category.Duration), category.Share*100)
+	}
+}
+
+func seconds(value int64) time.Duration {
+	return time.Duration(value) * time.Second
+}
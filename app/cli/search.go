@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Search prints the activities matching the query, from the best match, with
+// the matching words in brackets.
+func (c *CommandLine) Search(filter *types.SearchFilter) {
+	uri := fmt.Sprintf("%s/activities/_/search", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	query := reportQueryOf(&filter.PeriodFilter)
+	query.Set("q", filter.Query)
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var results []*types.SearchResult
+	err = json.Unmarshal(body, &results)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No activities found")
+		return
+	}
+	for _, result := range results {
+		c.PrintSearchResult(result)
+	}
+}
+
+var highlightReplacer = strings.NewReplacer("<mark>", "[", "</mark>", "]")
+
+func (c *CommandLine) PrintSearchResult(r *types.SearchResult) {
+	fmt.Println("-- Activity")
+	fmt.Println("     ID:         ", r.ID)
+	fmt.Printf("     Category:    %s (ID=%d)\n", r.Category, r.CategoryID)
+	fmt.Println("     Description:", highlightReplacer.Replace(r.Highlight))
+	if len(r.Tags) > 0 {
+		fmt.Println("     Tags:       ", "#"+strings.Join(r.Tags, " #"))
+	}
+	if r.StartedAt != nil {
+		fmt.Println("     Started:    ", r.StartedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Printf("     Score:       %.4f\n", r.Score)
+	fmt.Println("")
+}
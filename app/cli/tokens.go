@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"log"
+	"net/http"
+)
+
+func (c *CommandLine) PrintToken(token *types.Token) {
+	fmt.Println("-- Token")
+	fmt.Println("     ID:   ", token.ID)
+	fmt.Println("     Name: ", token.Name)
+	fmt.Println("     Scope:", token.Scope)
+	if token.Secret != "" {
+		fmt.Println("     Token:", token.Secret)
+	}
+	if token.CreatedAt != nil {
+		fmt.Println("     Created:", token.CreatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if token.RevokedAt != nil {
+		fmt.Println("     Revoked:", token.RevokedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("")
+}
+
+func (c *CommandLine) ListTokens() {
+	uri := fmt.Sprintf("%s/tokens", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var tokens []*types.Token
+	err = json.Unmarshal(body, &tokens)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	for _, token := range tokens {
+		c.PrintToken(token)
+	}
+}
+
+// CreateToken creates a token of the user and prints its secret, which the
+// server does not show again.
+func (c *CommandLine) CreateToken(input *types.Token) {
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	uri := fmt.Sprintf("%s/tokens", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(payload))
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var token types.Token
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	c.PrintToken(&token)
+}
+
+func (c *CommandLine) RevokeToken(id int64) {
+
+	uri := fmt.Sprintf("%s/tokens/%d", c.baseURL, id)
+
+	req, err := http.NewRequest(http.MethodDelete, uri, nil)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(res.Body)
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	fmt.Println("Token revoked:", res.Header.Get(httpext.HeaderEntity))
+}
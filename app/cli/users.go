@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+)
+
+func (c *CommandLine) PrintUser(user *types.User) {
+	fmt.Println("-- User")
+	fmt.Println("     ID:  ", user.ID)
+	fmt.Println("     Name:", user.Name)
+	if user.CreatedAt != nil {
+		fmt.Println("     Created:", user.CreatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	if user.UpdatedAt != nil {
+		fmt.Println("     Updated:", user.UpdatedAt.In(c.location).Format(timeext.DateTimeFormat))
+	}
+	fmt.Println("")
+}
+
+func (c *CommandLine) ListUsers() {
+	uri := fmt.Sprintf("%s/users", c.baseURL)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res != nil && res.Body != nil {
+		defer ioext.Close(res.Body)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		fmt.Println("[ERROR]", problemOf(res, body))
+		return
+	}
+
+	var users []*types.User
+	err = json.Unmarshal(body, &users)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	for _, user := range users {
+		c.PrintUser(user)
+	}
+}
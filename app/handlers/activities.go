@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/problem"
 	"github.com/ungame/timetrack/app/service"
 	"github.com/ungame/timetrack/httpext"
 	"github.com/ungame/timetrack/queries"
+	"github.com/ungame/timetrack/timeext"
 	"github.com/ungame/timetrack/types"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type activitiesHandler struct {
@@ -24,134 +29,322 @@ func NewActivitiesHandler(activitiesService service.ActivitiesService) Handler {
 func (a *activitiesHandler) Register(router *mux.Router) {
 	router.Path("/activities").HandlerFunc(a.PostActivity).Methods(http.MethodPost)
 	router.Path("/activities").HandlerFunc(a.GetActivities).Methods(http.MethodGet)
+	// registered before /activities/{id}, which would match it otherwise
+	router.Path("/activities/export.csv").HandlerFunc(a.ExportActivities).Methods(http.MethodGet)
 	router.Path("/activities/{id}").HandlerFunc(a.GetActivity).Methods(http.MethodGet)
 	router.Path("/activities/{id}").HandlerFunc(a.PutActivity).Methods(http.MethodPut)
 	router.Path("/activities/{id}").HandlerFunc(a.DeleteActivity).Methods(http.MethodDelete)
 	router.Path("/activities/{id}/finish").HandlerFunc(a.PutFinishActivity).Methods(http.MethodPut)
+	router.Path("/activities/{id}/pause").HandlerFunc(a.PutPauseActivity).Methods(http.MethodPut)
+	router.Path("/activities/{id}/resume").HandlerFunc(a.PutResumeActivity).Methods(http.MethodPut)
+	router.Path("/activities/{id}/changes").HandlerFunc(a.GetActivityChanges).Methods(http.MethodGet)
 	router.Path("/activities/_/filter").HandlerFunc(a.FilterActivities).Methods(http.MethodGet)
+	router.Path("/activities/_/manual").HandlerFunc(a.PostManualActivity).Methods(http.MethodPost)
+	router.Path("/activities/_/import").HandlerFunc(a.ImportActivities).Methods(http.MethodPost)
 }
 
 func (a *activitiesHandler) PostActivity(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, service.BadRequest(err))
 		return
 	}
 	input := new(types.Activity)
 	err = json.Unmarshal(body, input)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, service.BadRequest(err))
 		return
 	}
-	activity, err := a.activitiesService.StartActivity(r.Context(), input)
+	activity, err := a.activitiesService.StartActivity(r.Context(), middlewares.UserID(r), input)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, err)
 		return
 	}
 	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, activity.ID))
 	httpext.WriteJson(w, http.StatusCreated, activity)
 }
 
+func (a *activitiesHandler) PostManualActivity(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Activity)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	activity, err := a.activitiesService.AddActivity(r.Context(), middlewares.UserID(r), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("/activities/%d", activity.ID))
+	httpext.WriteJson(w, http.StatusCreated, activity)
+}
+
+// maxImportSize limits the body of an import.
+const maxImportSize = 32 << 20
+
+// ImportActivities records the activities of a CSV or JSON lines body, as
+// told by its content type, and responds with the outcome of each row. Times
+// without offset are read in the timezone of the request, and nothing is
+// written when the dry_run query parameter is true.
+func (a *activitiesHandler) ImportActivities(w http.ResponseWriter, r *http.Request) {
+	format, err := importFormatOf(r.Header.Get(httpext.HeaderContentType))
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	var dryRun bool
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			problem.Write(w, r, invalidParam("dry_run", "must be a boolean"))
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	result, err := a.activitiesService.ImportActivities(r.Context(), middlewares.UserID(r), format, body, timezoneOf(r), dryRun)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, result)
+}
+
+func importFormatOf(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, err.Error())
+	}
+	switch mediaType {
+	case httpext.MimeCSV:
+		return types.ImportFormatCSV, nil
+	case httpext.MimeNDJSON, httpext.MimeJSON:
+		return types.ImportFormatJSON, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+}
+
 func (a *activitiesHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
 		return
 	}
-	activity, err := a.activitiesService.GetActivity(r.Context(), id)
+	activity, err := a.activitiesService.GetActivity(r.Context(), middlewares.UserID(r), id)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, err)
 		return
 	}
 	httpext.WriteJson(w, http.StatusOK, activity)
 }
 
 func (a *activitiesHandler) GetActivities(w http.ResponseWriter, r *http.Request) {
-	activities, err := a.activitiesService.GetActivities(r.Context())
+	filter, err := filterOf(r)
 	if err != nil {
-		httpext.WriteError(w, http.StatusInternalServerError, err)
+		problem.Write(w, r, err)
 		return
 	}
+	page, err := pageOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	activities, next, err := a.activitiesService.GetActivities(r.Context(), middlewares.UserID(r), filter, page)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	if next != nil {
+		httpext.SetNextLink(w, nextPageURI(r, next))
+	}
 	httpext.WriteJson(w, http.StatusOK, activities)
 }
 
 func (a *activitiesHandler) FilterActivities(w http.ResponseWriter, r *http.Request) {
-	var (
-		query  = r.URL.Query()
-		period = queries.Today.String()
-		order  = queries.Desc.String()
-		limit  = 1000
-		err    error
-	)
+	filter, err := activityFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	activities, err := a.activitiesService.FilterActivitiesByPeriod(r.Context(), middlewares.UserID(r), filter)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, activities)
+}
 
-	if query.Get("period") != "" {
-		period = query.Get("period")
+// ExportActivities writes the filtered activities as CSV, with timestamps in
+// the timezone of the request.
+func (a *activitiesHandler) ExportActivities(w http.ResponseWriter, r *http.Request) {
+	filter, err := activityFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	activities, location, err := a.activitiesService.ExportActivities(r.Context(), middlewares.UserID(r), filter)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
 	}
 
-	if query.Get("order") != "" {
-		order = query.Get("order")
+	records := make([][]string, 0, len(activities)+1)
+	records = append(records, activityCSVHeader)
+	for _, activity := range activities {
+		records = append(records, activityCSVRecord(activity, location))
 	}
+	httpext.WriteCSV(w, http.StatusOK, "activities.csv", records)
+}
 
-	if query.Get("limit") != "" {
-		limit, err = strconv.Atoi(query.Get("limit"))
-		if err != nil {
-			httpext.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
+var activityCSVHeader = []string{
+	"id",
+	"category_id",
+	"category",
+	"description",
+	"status",
+	"started_at",
+	"finished_at",
+	"timezone",
+	"duration_seconds",
+	"duration",
+}
+
+func activityCSVRecord(activity *types.ActivityRecord, location *time.Location) []string {
+	return []string{
+		strconv.FormatInt(activity.ID, 10),
+		strconv.FormatInt(activity.CategoryID, 10),
+		activity.Category,
+		activity.Description,
+		activity.Status,
+		formatCSVTime(activity.StartedAt),
+		formatCSVTime(activity.FinishedAt),
+		location.String(),
+		strconv.FormatInt(activity.Duration, 10),
+		timeext.FormatHoursMinutes(time.Duration(activity.Duration) * time.Second),
 	}
+}
 
-	filter := &types.PeriodFilter{
-		PeriodName: period,
-		OrderBy:    order,
-		Limit:      limit,
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
 	}
+	return t.Format(timeext.DateTimeFormat)
+}
 
-	activities, err := a.activitiesService.FilterActivitiesByPeriod(r.Context(), filter)
+// activityFilterOf reads the period filter of the request along with the
+// order and limit query parameters.
+func activityFilterOf(r *http.Request) (*types.PeriodFilter, error) {
+	filter, err := periodFilterOf(r)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
-		return
+		return nil, err
 	}
-	httpext.WriteJson(w, http.StatusOK, activities)
+	query := r.URL.Query()
+
+	filter.OrderBy = queries.Desc.String()
+	if query.Get("order") != "" {
+		filter.OrderBy = query.Get("order")
+	}
+
+	filter.Limit = 1000
+	if query.Get("limit") != "" {
+		filter.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			return nil, invalidParam("limit", "must be an integer")
+		}
+	}
+
+	return filter, nil
 }
 
 func (a *activitiesHandler) PutActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
 		return
 	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, service.BadRequest(err))
 		return
 	}
 	input := new(types.Activity)
 	err = json.Unmarshal(body, input)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, service.BadRequest(err))
 		return
 	}
 	input.ID = id
-	activity, err := a.activitiesService.UpdateActivity(r.Context(), input)
+	activity, err := a.activitiesService.UpdateActivity(r.Context(), middlewares.UserID(r), input)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, err)
 		return
 	}
 	httpext.WriteJson(w, http.StatusOK, activity)
 }
 
+func (a *activitiesHandler) GetActivityChanges(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	changes, err := a.activitiesService.GetActivityChanges(r.Context(), middlewares.UserID(r), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, changes)
+}
+
 func (a *activitiesHandler) PutFinishActivity(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	activity, err := a.activitiesService.FinishActivity(r.Context(), middlewares.UserID(r), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, activity)
+}
+
+func (a *activitiesHandler) PutPauseActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	activity, err := a.activitiesService.PauseActivity(r.Context(), middlewares.UserID(r), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, activity)
+}
+
+func (a *activitiesHandler) PutResumeActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
 		return
 	}
-	activity, err := a.activitiesService.FinishActivity(r.Context(), id)
+	activity, err := a.activitiesService.ResumeActivity(r.Context(), middlewares.UserID(r), id)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, err)
 		return
 	}
 	httpext.WriteJson(w, http.StatusOK, activity)
@@ -161,12 +354,12 @@ func (a *activitiesHandler) DeleteActivity(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
 		return
 	}
-	err = a.activitiesService.DeleteActivity(r.Context(), id)
+	err = a.activitiesService.DeleteActivity(r.Context(), middlewares.UserID(r), id)
 	if err != nil {
-		httpext.WriteError(w, http.StatusUnprocessableEntity, err)
+		problem.Write(w, r, err)
 		return
 	}
 	w.Header().Set(httpext.HeaderEntity, fmt.Sprint(id))
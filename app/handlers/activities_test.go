@@ -0,0 +1,1034 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const parallelRequests = 20
+
+func TestActivitiesHandler_PostActivityInParallel(t *testing.T) {
+	server := newTestServer(t, timeext.NewSystemClock())
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			postActivity(t, server, fmt.Sprintf("parallel #%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	assertSingleRunning(t, getActivities(t, server), parallelRequests, time.Time{})
+}
+
+func TestActivitiesHandler_ResumeActivityInParallel(t *testing.T) {
+	server := newTestServer(t, timeext.NewSystemClock())
+
+	paused := postActivity(t, server, "paused")
+	paused = putActivity(t, server, paused.ID, "pause")
+	pausedAt := *paused.Intervals[0].FinishedAt
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == parallelRequests/2 {
+				putActivity(t, server, paused.ID, "resume")
+				return
+			}
+			postActivity(t, server, fmt.Sprintf("parallel #%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	assertSingleRunning(t, getActivities(t, server), parallelRequests, pausedAt)
+}
+
+func TestActivitiesHandler_PostActivityFinishesRunning(t *testing.T) {
+	var (
+		startedAt = time.Date(2022, time.November, 1, 9, 0, 0, 0, time.UTC)
+		clock     = timeext.NewFrozenClock(startedAt)
+		server    = newTestServer(t, clock)
+	)
+
+	first := postActivity(t, server, "first")
+	finishedAt := clock.Add(30 * time.Minute)
+	second := postActivity(t, server, "second")
+	clock.Add(15 * time.Minute)
+
+	for _, activity := range getActivities(t, server) {
+		switch activity.ID {
+		case first.ID:
+			if activity.Status != models.Finished.String() || !activity.FinishedAt.Equal(finishedAt) {
+				t.Errorf("first activity must finish when the second starts: %+v", activity)
+			}
+			if activity.Duration != int64((30 * time.Minute).Seconds()) {
+				t.Errorf("unexpected duration: expected=%d, got=%d", int64((30 * time.Minute).Seconds()), activity.Duration)
+			}
+		case second.ID:
+			if activity.Status != models.Started.String() || !activity.StartedAt.Equal(finishedAt) {
+				t.Errorf("second activity must be running: %+v", activity)
+			}
+			if activity.Duration != int64((15 * time.Minute).Seconds()) {
+				t.Errorf("unexpected duration of the running activity: expected=%d, got=%d", int64((15 * time.Minute).Seconds()), activity.Duration)
+			}
+		}
+	}
+}
+
+func TestActivitiesHandler_PauseAndResumeActivity(t *testing.T) {
+	var (
+		startedAt = time.Date(2022, time.November, 1, 9, 0, 0, 0, time.UTC)
+		clock     = timeext.NewFrozenClock(startedAt)
+		server    = newTestServer(t, clock)
+	)
+
+	activity := postActivity(t, server, "paused")
+	path := fmt.Sprintf("/activities/%d", activity.ID)
+	send(t, server, http.MethodPut, path+"/resume", nil, http.StatusUnprocessableEntity, nil)
+
+	clock.Add(20 * time.Minute)
+	putActivity(t, server, activity.ID, "pause")
+	send(t, server, http.MethodPut, path+"/pause", nil, http.StatusUnprocessableEntity, nil)
+
+	clock.Add(time.Hour)
+	assertDuration(t, server, activity.ID, 20*time.Minute)
+	putActivity(t, server, activity.ID, "resume")
+
+	clock.Add(10 * time.Minute)
+	assertDuration(t, server, activity.ID, 30*time.Minute)
+	activity = putActivity(t, server, activity.ID, "finish")
+	send(t, server, http.MethodPut, path+"/pause", nil, http.StatusUnprocessableEntity, nil)
+	send(t, server, http.MethodPut, path+"/resume", nil, http.StatusUnprocessableEntity, nil)
+
+	if activity.Status != models.Finished.String() || !activity.FinishedAt.Equal(startedAt.Add(90*time.Minute)) {
+		t.Errorf("unexpected finished activity: %+v", activity)
+	}
+	if activity.Duration != int64((30 * time.Minute).Seconds()) {
+		t.Errorf("paused time must not count: expected=%d, got=%d", int64((30 * time.Minute).Seconds()), activity.Duration)
+	}
+	if len(activity.Intervals) != 2 {
+		t.Fatalf("unexpected intervals: expected=2, got=%d", len(activity.Intervals))
+	}
+	for i, expected := range [][2]time.Duration{{0, 20 * time.Minute}, {80 * time.Minute, 90 * time.Minute}} {
+		interval := activity.Intervals[i]
+		if !interval.StartedAt.Equal(startedAt.Add(expected[0])) || !interval.FinishedAt.Equal(startedAt.Add(expected[1])) {
+			t.Errorf("unexpected interval %d: %+v", i, interval)
+		}
+	}
+}
+
+// assertDuration checks the duration of the activity at the time of the clock
+// of the server.
+func assertDuration(t *testing.T, server *httptest.Server, id int64, expected time.Duration) {
+	t.Helper()
+
+	activity := new(types.Activity)
+	send(t, server, http.MethodGet, fmt.Sprintf("/activities/%d", id), nil, http.StatusOK, activity)
+	if activity.Duration != int64(expected.Seconds()) {
+		t.Errorf("unexpected duration of activity %d: expected=%d, got=%d", id, int64(expected.Seconds()), activity.Duration)
+	}
+}
+
+func TestActivitiesHandler_PostManualActivity(t *testing.T) {
+	var (
+		now    = time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(now))
+		at     = func(hour, minute int) *time.Time {
+			t := time.Date(2022, time.November, 1, hour, minute, 0, 0, time.UTC)
+			return &t
+		}
+	)
+
+	existing := postManualActivity(t, server, 2, *at(9, 0), *at(10, 0))
+
+	tests := []struct {
+		name       string
+		startedAt  *time.Time
+		finishedAt *time.Time
+		status     int
+	}{
+		{"missing start", nil, at(11, 0), http.StatusUnprocessableEntity},
+		{"missing end", at(11, 0), nil, http.StatusUnprocessableEntity},
+		{"end before start", at(11, 0), at(10, 30), http.StatusUnprocessableEntity},
+		{"end at start", at(11, 0), at(11, 0), http.StatusUnprocessableEntity},
+		{"end in the future", at(11, 0), at(12, 1), http.StatusUnprocessableEntity},
+		{"overlapping end", at(8, 30), at(9, 1), http.StatusConflict},
+		{"overlapping start", at(9, 59), at(10, 30), http.StatusConflict},
+		{"within", at(9, 15), at(9, 45), http.StatusConflict},
+		{"around", at(8, 0), at(11, 0), http.StatusConflict},
+		{"before", at(8, 0), at(9, 0), http.StatusCreated},
+		{"after", at(10, 0), at(12, 0), http.StatusCreated},
+	}
+
+	for _, test := range tests {
+		input := &types.Activity{CategoryID: 2, StartedAt: test.startedAt, FinishedAt: test.finishedAt}
+		send(t, server, http.MethodPost, "/activities/_/manual", input, test.status, nil)
+	}
+
+	send(t, server, http.MethodGet, fmt.Sprintf("/activities/%d", existing.ID), nil, http.StatusOK, existing)
+	if n := len(getActivities(t, server)); n != 3 {
+		t.Errorf("unexpected activities: expected=3, got=%d", n)
+	}
+}
+
+func TestActivitiesHandler_PostManualActivityInParallel(t *testing.T) {
+	var (
+		now    = time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(now))
+	)
+
+	var (
+		wg       sync.WaitGroup
+		statuses = make(chan int, parallelRequests)
+	)
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// every activity overlaps all the others
+			startedAt := now.Add(-time.Duration(2*parallelRequests-i) * time.Minute)
+			finishedAt := startedAt.Add(time.Duration(parallelRequests) * time.Minute)
+			statuses <- postStatus(t, server, "/activities/_/manual", &types.Activity{CategoryID: 2, StartedAt: &startedAt, FinishedAt: &finishedAt})
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	count := make(map[int]int)
+	for status := range statuses {
+		count[status]++
+	}
+	if count[http.StatusCreated] != 1 || count[http.StatusConflict] != parallelRequests-1 {
+		t.Errorf("unexpected statuses: %v", count)
+	}
+}
+
+// TestActivitiesHandler_PutActivityInParallel checks that a correction of an
+// activity racing the addition of others at the same time doesn't save
+// overlapping activities.
+func TestActivitiesHandler_PutActivityInParallel(t *testing.T) {
+	var (
+		today    = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server   = newTestServer(t, timeext.NewFrozenClock(today.Add(18*time.Hour)))
+		activity = postManualActivity(t, server, 2, today.Add(8*time.Hour), today.Add(8*time.Hour+15*time.Minute))
+	)
+
+	var (
+		wg       sync.WaitGroup
+		statuses = make(chan int, parallelRequests)
+	)
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// the correction and the added activities overlap each other
+			if i == 0 {
+				startedAt, finishedAt := today.Add(8*time.Hour), today.Add(10*time.Hour)
+				statuses <- sendStatus(t, server, http.MethodPut, fmt.Sprintf("/activities/%d", activity.ID), &types.Activity{StartedAt: &startedAt, FinishedAt: &finishedAt})
+				return
+			}
+			startedAt := today.Add(8*time.Hour + 30*time.Minute + time.Duration(i)*time.Minute)
+			finishedAt := startedAt.Add(time.Hour)
+			statuses <- postStatus(t, server, "/activities/_/manual", &types.Activity{CategoryID: 2, StartedAt: &startedAt, FinishedAt: &finishedAt})
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	count := make(map[int]int)
+	for status := range statuses {
+		count[status]++
+	}
+	if count[http.StatusOK]+count[http.StatusCreated] != 1 || count[http.StatusConflict] != parallelRequests-1 {
+		t.Errorf("unexpected statuses: %v", count)
+	}
+}
+
+func TestActivitiesHandler_PutActivity(t *testing.T) {
+	var (
+		clock  = timeext.NewFrozenClock(time.Date(2022, time.November, 1, 11, 40, 0, 0, time.UTC))
+		server = newTestServer(t, clock)
+		at     = func(hour, minute int) *time.Time {
+			t := time.Date(2022, time.November, 1, hour, minute, 0, 0, time.UTC)
+			return &t
+		}
+	)
+
+	postManualActivity(t, server, 1, *at(8, 0), *at(9, 0))
+	finished := new(types.Activity)
+	send(t, server, http.MethodPost, "/activities/_/manual", &types.Activity{
+		CategoryID:  2,
+		Description: "api",
+		StartedAt:   at(9, 30),
+		FinishedAt:  at(10, 30),
+	}, http.StatusCreated, finished)
+	postManualActivity(t, server, 1, *at(11, 0), *at(11, 30))
+	running := postActivity(t, server, "running")
+	clock.Set(*at(12, 0))
+
+	runningPath := fmt.Sprintf("/activities/%d", running.ID)
+	for _, test := range []struct {
+		input  *types.Activity
+		status int
+	}{
+		{&types.Activity{FinishedAt: at(11, 50)}, http.StatusUnprocessableEntity},
+		{&types.Activity{StartedAt: at(12, 30)}, http.StatusUnprocessableEntity},
+		{&types.Activity{StartedAt: at(11, 20)}, http.StatusConflict},
+		{&types.Activity{StartedAt: at(11, 35), Description: "timer"}, http.StatusOK},
+	} {
+		var output any
+		if test.status == http.StatusOK {
+			output = running
+		}
+		send(t, server, http.MethodPut, runningPath, test.input, test.status, output)
+	}
+	if !running.StartedAt.Equal(*at(11, 35)) || !running.Intervals[0].StartedAt.Equal(*at(11, 35)) {
+		t.Errorf("unexpected start of the running activity: %+v", running)
+	}
+
+	finishedPath := fmt.Sprintf("/activities/%d", finished.ID)
+	for _, test := range []struct {
+		input  *types.Activity
+		status int
+	}{
+		{&types.Activity{FinishedAt: at(9, 0)}, http.StatusUnprocessableEntity},
+		{&types.Activity{FinishedAt: at(12, 30)}, http.StatusUnprocessableEntity},
+		{&types.Activity{StartedAt: at(8, 30)}, http.StatusConflict},
+		{&types.Activity{FinishedAt: at(11, 15)}, http.StatusConflict},
+		{&types.Activity{StartedAt: at(11, 31), FinishedAt: at(11, 50)}, http.StatusConflict},
+		{&types.Activity{CategoryID: 3, StartedAt: at(9, 15), FinishedAt: at(10, 45)}, http.StatusOK},
+	} {
+		var output any
+		if test.status == http.StatusOK {
+			output = finished
+		}
+		send(t, server, http.MethodPut, finishedPath, test.input, test.status, output)
+	}
+	if finished.CategoryID != 3 || finished.Description != "" || finished.Duration != 5400 {
+		t.Errorf("unexpected corrected activity: %+v", finished)
+	}
+	if interval := finished.Intervals[0]; !interval.StartedAt.Equal(*at(9, 15)) || !interval.FinishedAt.Equal(*at(10, 45)) {
+		t.Errorf("unexpected corrected interval: %+v", interval)
+	}
+
+	assertChanges(t, server, running.ID, []string{
+		"description: running -> timer",
+		"started_at: 2022-11-01T11:40:00Z -> 2022-11-01T11:35:00Z",
+	})
+	assertChanges(t, server, finished.ID, []string{
+		"category_id: 2 -> 3",
+		"description: api -> ",
+		"started_at: 2022-11-01T09:30:00Z -> 2022-11-01T09:15:00Z",
+		"finished_at: 2022-11-01T10:30:00Z -> 2022-11-01T10:45:00Z",
+	})
+	send(t, server, http.MethodGet, "/activities/404/changes", nil, http.StatusNotFound, nil)
+}
+
+// assertChanges compares the changes of the activity, formatted as
+// "field: old -> new", with the expected ones.
+func assertChanges(t *testing.T, server *httptest.Server, id int64, expected []string) {
+	t.Helper()
+
+	var changes []*types.ActivityChange
+	send(t, server, http.MethodGet, fmt.Sprintf("/activities/%d/changes", id), nil, http.StatusOK, &changes)
+
+	got := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.ActivityID != id || change.ChangedAt == nil {
+			t.Errorf("unexpected change of activity %d: %+v", id, change)
+		}
+		got = append(got, fmt.Sprintf("%s: %s -> %s", change.Field, stringOf(change.OldValue), stringOf(change.NewValue)))
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected changes of activity %d: expected=%q, got=%q", id, expected, got)
+	}
+}
+
+func stringOf(value *string) string {
+	if value == nil {
+		return "<nil>"
+	}
+	return *value
+}
+
+// TestActivitiesHandler_FilterActivitiesUntilEndOfDay checks that a date-only
+// to includes the activities started within the last second of the day.
+func TestActivitiesHandler_FilterActivitiesUntilEndOfDay(t *testing.T) {
+	var (
+		clock  = timeext.NewFrozenClock(time.Date(2022, time.November, 1, 23, 59, 59, 5e8, time.UTC))
+		server = newTestServer(t, clock)
+	)
+	activity := postActivity(t, server, "late")
+	clock.Add(time.Minute)
+	putActivity(t, server, activity.ID, "finish")
+
+	for _, path := range []string{
+		"/activities/_/filter?from=2022-11-01&to=2022-11-01&tz=UTC",
+		"/activities/_/filter?period=yesterday&tz=UTC",
+	} {
+		if activities := listActivities(t, server, path); len(activities) != 1 {
+			t.Errorf("unexpected activities of %s: expected=1, got=%d", path, len(activities))
+		}
+	}
+}
+
+func TestActivitiesHandler_FilterActivitiesInTimezone(t *testing.T) {
+	server := newTestServer(t, timeext.NewSystemClock())
+
+	location := time.FixedZone("UTC-3", -3*60*60)
+	startedAt := time.Date(2022, time.November, 1, 22, 0, 0, 0, location)
+	finishedAt := startedAt.Add(time.Hour)
+	postManualActivity(t, server, 1, startedAt, finishedAt)
+
+	tests := map[string]int{
+		"America/Sao_Paulo": 1,
+		"UTC":               0,
+	}
+	for tz, expected := range tests {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/activities/_/filter?from=2022-11-01&to=2022-11-01", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(httpext.HeaderTimezone, tz)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var activities []*types.Activity
+		err = json.NewDecoder(res.Body).Decode(&activities)
+		ioext.Close(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(activities) != expected {
+			t.Errorf("unexpected activities in %s: expected=%d, got=%d", tz, expected, len(activities))
+		}
+	}
+}
+
+func TestActivitiesHandler_ExportActivities(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(12*time.Hour)))
+	)
+
+	activity := postManualActivity(t, server, 1, today.Add(9*time.Hour), today.Add(10*time.Hour+30*time.Minute))
+	formula := postManualActivity(t, server, 2, today.Add(11*time.Hour), today.Add(11*time.Hour+15*time.Minute))
+	send(t, server, http.MethodPut, fmt.Sprintf("/activities/%d", formula.ID), &types.Activity{Description: `=HYPERLINK("http://example.com")`}, http.StatusOK, nil)
+
+	res, err := http.Get(server.URL + "/activities/export.csv?from=2022-11-01&tz=America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != http.StatusOK || res.Header.Get(httpext.HeaderContentType) != httpext.MimeCSV {
+		t.Fatalf("unexpected response: status=%d, content-type=%s", res.StatusCode, res.Header.Get(httpext.HeaderContentType))
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"id", "category_id", "category", "description", "status", "started_at", "finished_at", "timezone", "duration_seconds", "duration"},
+		{fmt.Sprint(formula.ID), "2", "coding", `'=HYPERLINK("http://example.com")`, "FINISHED", "2022-11-01 08:00:00", "2022-11-01 08:15:00", "America/Sao_Paulo", "900", "00:15"},
+		{fmt.Sprint(activity.ID), "1", "meeting", "", "FINISHED", "2022-11-01 06:00:00", "2022-11-01 07:30:00", "America/Sao_Paulo", "5400", "01:30"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("unexpected records: \nexpected=%v \ngot=%v", expected, records)
+	}
+
+	// the export is imported back as the activities it was exported from
+	result := postImport(t, server, httpext.MimeCSV, string(body), false)
+	assertImportRows(t, result.Rows, []*types.ImportRow{
+		{Row: 2, Status: types.ImportStatusSkipped, ID: formula.ID},
+		{Row: 3, Status: types.ImportStatusSkipped, ID: activity.ID},
+	})
+}
+
+// TestActivitiesHandler_ImportExportedTimerActivity checks that an activity
+// started by the timer, stored with fractions of a second, is skipped when its
+// export, which has whole seconds, is imported back.
+func TestActivitiesHandler_ImportExportedTimerActivity(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(9*time.Hour + 250*time.Millisecond))
+		server = newTestServer(t, clock)
+	)
+
+	activity := postActivity(t, server, "timer")
+	clock.Add(30*time.Minute + 500*time.Millisecond)
+	putActivity(t, server, activity.ID, "finish")
+
+	res, err := http.Get(server.URL + "/activities/export.csv?from=2022-11-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := postImport(t, server, httpext.MimeCSV, string(body), false)
+	assertImportRows(t, result.Rows, []*types.ImportRow{
+		{Row: 2, Status: types.ImportStatusSkipped, ID: activity.ID},
+	})
+}
+
+func TestActivitiesHandler_ImportActivities(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(18*time.Hour)))
+	)
+
+	existing := postManualActivity(t, server, 1, today.Add(9*time.Hour), today.Add(10*time.Hour+30*time.Minute))
+
+	body := strings.Join([]string{
+		"id,category_id,category,description,status,started_at,finished_at,timezone",
+		fmt.Sprintf("%d,1,meeting,,FINISHED,2022-11-01 06:00:00,2022-11-01 07:30:00,America/Sao_Paulo", existing.ID),
+		",,coding,api,,2022-11-01 08:00,2022-11-01 09:00,America/Sao_Paulo",
+		",,golf,,,2022-11-01 13:00,2022-11-01 14:00,",
+		",,review,,,2022-11-01 11:30,2022-11-01 12:30,",
+		",3,,,,2022-11-01 15:00,2022-11-01 14:00,",
+		",,coding,api,,2022-11-01T11:00:00Z,2022-11-01T12:00:00Z,",
+	}, "\n")
+
+	expected := []*types.ImportRow{
+		{Row: 2, Status: types.ImportStatusSkipped, ID: existing.ID},
+		{Row: 3, Status: types.ImportStatusImported},
+		{Row: 4, Status: types.ImportStatusFailed},
+		{Row: 5, Status: types.ImportStatusFailed},
+		{Row: 6, Status: types.ImportStatusFailed},
+		{Row: 7, Status: types.ImportStatusSkipped},
+	}
+
+	result := postImport(t, server, httpext.MimeCSV, body, true)
+	assertImportRows(t, result.Rows, expected)
+	if n := len(getActivities(t, server)); n != 1 {
+		t.Fatalf("dry run must not import: expected=1, got=%d", n)
+	}
+
+	result = postImport(t, server, httpext.MimeCSV, body, false)
+	imported := result.Rows[1].ID
+	expected[1].ID, expected[5].ID = imported, imported
+	assertImportRows(t, result.Rows, expected)
+	if result.Imported != 1 || result.Skipped != 2 || result.Failed != 3 {
+		t.Errorf("unexpected totals: imported=%d, skipped=%d, failed=%d", result.Imported, result.Skipped, result.Failed)
+	}
+	if n := len(getActivities(t, server)); n != 2 {
+		t.Fatalf("unexpected activities: expected=2, got=%d", n)
+	}
+
+	lines := strings.Join([]string{
+		`{"category":"coding","description":"api","started_at":"2022-11-01T08:00:00-03:00","finished_at":"2022-11-01T09:00:00-03:00"}`,
+		``,
+		`{"category_id":5,"started_at":"2022-11-01T16:00:00Z","finished_at":"2022-11-01T17:00:00Z"}`,
+		`{"category_id":5,`,
+	}, "\n")
+
+	result = postImport(t, server, httpext.MimeNDJSON, lines, false)
+	assertImportRows(t, result.Rows, []*types.ImportRow{
+		{Row: 1, Status: types.ImportStatusSkipped, ID: imported},
+		{Row: 3, Status: types.ImportStatusImported, ID: imported + 1},
+		{Row: 4, Status: types.ImportStatusFailed},
+	})
+}
+
+func TestActivitiesHandler_ImportMultilineCSV(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(18*time.Hour)))
+	)
+
+	body := strings.Join([]string{
+		"category,description,started_at,finished_at",
+		"coding,\"api",
+		"tests\",2022-11-01 08:00,2022-11-01 09:00",
+		"coding,,2022-11-01 15:00,2022-11-01 14:00",
+		"coding,bare\"quote,2022-11-01 10:00,2022-11-01 11:00",
+		"coding,,2022-11-01 12:00,2022-11-01 13:00",
+	}, "\n")
+
+	result := postImport(t, server, httpext.MimeCSV, body, true)
+	assertImportRows(t, result.Rows, []*types.ImportRow{
+		{Row: 2, Status: types.ImportStatusImported},
+		{Row: 4, Status: types.ImportStatusFailed},
+		{Row: 5, Status: types.ImportStatusFailed},
+		{Row: 6, Status: types.ImportStatusImported},
+	})
+}
+
+func TestActivitiesHandler_Problems(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(18*time.Hour)))
+	)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		field       string
+	}{
+		{"malformed id", http.MethodGet, "/activities/abc", httpext.MimeJSON, "", http.StatusBadRequest, "bad_request", "id"},
+		{"malformed body", http.MethodPost, "/activities", httpext.MimeJSON, "{", http.StatusBadRequest, "bad_request", ""},
+		{"invalid field", http.MethodPost, "/activities/_/manual", httpext.MimeJSON, `{"category_id":1,"started_at":"2022-11-01T10:00:00Z","finished_at":"2022-11-01T09:00:00Z"}`, http.StatusUnprocessableEntity, "invalid_activity", "finished_at"},
+		{"unknown category", http.MethodPost, "/activities", httpext.MimeJSON, `{"category_id":99}`, http.StatusUnprocessableEntity, "invalid_activity", "category_id"},
+		{"invalid filter", http.MethodGet, "/activities/_/filter?period=decade", httpext.MimeJSON, "", http.StatusBadRequest, "invalid_filter", "period"},
+		{"not found", http.MethodGet, "/activities/99", httpext.MimeJSON, "", http.StatusNotFound, "activity_not_found", ""},
+		{"unsupported media type", http.MethodPost, "/activities/_/import", "text/plain", "", http.StatusUnsupportedMediaType, "unsupported_media_type", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(httpext.HeaderContentType, test.contentType)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ioext.Close(res.Body)
+
+			problem := decodeProblem(t, res, test.status)
+			if problem.Code != test.code || problem.Type != "urn:timetrack:problem:"+test.code {
+				t.Errorf("unexpected code: expected=%s, got=%+v", test.code, problem)
+			}
+			if problem.Instance != req.URL.Path || problem.Detail == "" {
+				t.Errorf("unexpected instance or detail: %+v", problem)
+			}
+			var fields []string
+			for _, field := range problem.Fields {
+				fields = append(fields, field.Field)
+			}
+			if got := strings.Join(fields, ","); got != test.field {
+				t.Errorf("unexpected fields: expected=%q, got=%q", test.field, got)
+			}
+		})
+	}
+}
+
+// decodeProblem checks the status and the content type of the error response
+// and decodes its problem.
+func decodeProblem(t *testing.T, res *http.Response, status int) *types.Problem {
+	t.Helper()
+
+	if res.StatusCode != status {
+		t.Fatalf("unexpected status: expected=%d, got=%d", status, res.StatusCode)
+	}
+	if contentType := res.Header.Get(httpext.HeaderContentType); contentType != httpext.MimeProblem {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	problem := new(types.Problem)
+	if err := json.NewDecoder(res.Body).Decode(problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != status {
+		t.Errorf("unexpected status of the problem: expected=%d, got=%d", status, problem.Status)
+	}
+	return problem
+}
+
+func postImport(t *testing.T, server *httptest.Server, contentType, body string, dryRun bool) *types.ImportResult {
+	t.Helper()
+
+	uri := fmt.Sprintf("%s/activities/_/import?dry_run=%t", server.URL, dryRun)
+	res, err := http.Post(uri, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: expected=%d, got=%d", http.StatusOK, res.StatusCode)
+	}
+	result := new(types.ImportResult)
+	if err = json.NewDecoder(res.Body).Decode(result); err != nil {
+		t.Fatal(err)
+	}
+	if result.DryRun != dryRun {
+		t.Errorf("unexpected dry run: expected=%t, got=%t", dryRun, result.DryRun)
+	}
+	return result
+}
+
+// assertImportRows compares the rows ignoring the error messages, which must
+// be set on failed rows only.
+func assertImportRows(t *testing.T, got, expected []*types.ImportRow) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected rows: expected=%d, got=%d", len(expected), len(got))
+	}
+	for i, row := range got {
+		if row.Row != expected[i].Row || row.Status != expected[i].Status || row.ID != expected[i].ID {
+			t.Errorf("unexpected row: expected=%+v, got=%+v", expected[i], row)
+		}
+		if (row.Status == types.ImportStatusFailed) != (row.Error != "") {
+			t.Errorf("unexpected error of row %d: %q", row.Row, row.Error)
+		}
+	}
+}
+
+func TestActivitiesHandler_Tags(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(12 * time.Hour))
+		server = newTestServer(t, clock)
+	)
+
+	post := func(path string, activity *types.Activity, status int) *types.Activity {
+		payload, err := json.Marshal(activity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.Post(server.URL+path, httpext.MimeJSON, bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decodeActivity(t, res, status)
+	}
+
+	var (
+		startedAt  = today.Add(9 * time.Hour)
+		finishedAt = today.Add(10 * time.Hour)
+	)
+	manual := post("/activities/_/manual", &types.Activity{
+		CategoryID: 1,
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+		Tags:       []string{"oncall", "#OnCall"},
+	}, http.StatusCreated)
+	started := post("/activities", &types.Activity{CategoryID: 2, Tags: []string{"release-42", "oncall"}}, http.StatusCreated)
+	post("/activities", &types.Activity{CategoryID: 2, Tags: []string{"on call"}}, http.StatusUnprocessableEntity)
+	clock.Add(30 * time.Minute)
+
+	if !reflect.DeepEqual(manual.Tags, []string{"oncall"}) || !reflect.DeepEqual(started.Tags, []string{"oncall", "release-42"}) {
+		t.Fatalf("unexpected tags: manual=%v, started=%v", manual.Tags, started.Tags)
+	}
+
+	for path, expected := range map[string]int{
+		"/activities?tag=release-42":                       1,
+		"/activities?tag=%23OnCall":                        2,
+		"/activities?tag=missing":                          0,
+		"/activities/_/filter?period=today&tag=oncall":     2,
+		"/activities/_/filter?period=today&tag=release-42": 1,
+	} {
+		if activities := listActivities(t, server, path); len(activities) != expected {
+			t.Errorf("unexpected activities of %s: expected=%d, got=%d", path, expected, len(activities))
+		}
+	}
+
+	summary := getSummary(t, server, "period=today")
+	expected := []*types.TagSummary{
+		{Tag: "oncall", Count: 2, Duration: 5400},
+		{Tag: "release-42", Count: 1, Duration: 1800},
+	}
+	if !reflect.DeepEqual(summary.Tags, expected) {
+		t.Errorf("unexpected tags summary: expected=%v, got=%v", expected, summary.Tags)
+	}
+	summary = getSummary(t, server, "period=today&tag=release-42")
+	if summary.Count != 1 || summary.Duration != 1800 || len(summary.Categories) != 1 || summary.Categories[0].Category != "coding" {
+		t.Errorf("unexpected summary of release-42: %+v", summary)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/activities/%d", server.URL, manual.ID), strings.NewReader(`{"tags":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated := decodeActivity(t, res, http.StatusOK); updated == nil || len(updated.Tags) != 0 {
+		t.Fatalf("tags must be removed: %+v", updated)
+	}
+	if activities := listActivities(t, server, "/activities?tag=oncall"); len(activities) != 1 || activities[0].ID != started.ID {
+		t.Errorf("unexpected activities tagged oncall: %+v", activities)
+	}
+}
+
+func TestActivitiesHandler_GetActivitiesInPages(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(12*time.Hour)))
+		ids    = make([]int64, 0, 5)
+	)
+
+	for i := 0; i < 5; i++ {
+		var (
+			categoryID = int64(1 + i%2)
+			start      = today.Add(time.Duration(i) * time.Hour)
+		)
+		ids = append(ids, postManualActivity(t, server, categoryID, start, start.Add(30*time.Minute)).ID)
+	}
+
+	// pages follow the next links, which keep the other query parameters
+	pages := make([][]int64, 0, 3)
+	for next := "/activities?page_size=2"; next != ""; {
+		res, err := http.Get(server.URL + next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		activities := make([]*types.Activity, 0, 2)
+		err = json.NewDecoder(res.Body).Decode(&activities)
+		ioext.Close(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := make([]int64, 0, len(activities))
+		for _, activity := range activities {
+			page = append(page, activity.ID)
+		}
+		pages = append(pages, page)
+		next = httpext.NextLink(res.Header)
+	}
+	expected := [][]int64{ids[0:2], ids[2:4], ids[4:5]}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("unexpected pages: expected=%v, got=%v", expected, pages)
+	}
+
+	res, err := http.Get(fmt.Sprintf("%s/activities?page_size=2&after_id=%d", server.URL, ids[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioext.Close(res.Body)
+	if link := httpext.NextLink(res.Header); link != "" {
+		t.Errorf("unexpected link after the last page: %s", link)
+	}
+
+	if activities := listActivities(t, server, "/activities"); len(activities) != 5 {
+		t.Errorf("unexpected activities of the default page: expected=5, got=%d", len(activities))
+	}
+	if activities := listActivities(t, server, fmt.Sprintf("/activities?after_id=%d", ids[1])); len(activities) != 3 || activities[0].ID != ids[2] {
+		t.Errorf("unexpected activities after %d: %v", ids[1], activities)
+	}
+
+	for _, query := range []string{"page_size=-1", "page_size=1001", "page_size=x", "after_id=-1"} {
+		res, err = http.Get(server.URL + "/activities?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioext.Close(res.Body)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("unexpected status of %s: expected=%d, got=%d", query, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+}
+
+// assertSingleRunning checks that only the last started activity is running
+// and that every work interval started from since finished exactly when the
+// next one started.
+func assertSingleRunning(t *testing.T, activities []*types.Activity, expected int, since time.Time) {
+	t.Helper()
+
+	if len(activities) != expected {
+		t.Fatalf("unexpected activities: expected=%d, got=%d", expected, len(activities))
+	}
+
+	running := 0
+	for _, activity := range activities {
+		if activity.Status == models.Started.String() {
+			running++
+		}
+	}
+	if running != 1 {
+		t.Fatalf("unexpected running activities: expected=1, got=%d", running)
+	}
+
+	intervals := make([]*types.ActivityInterval, 0, len(activities))
+	for _, activity := range activities {
+		for _, interval := range activity.Intervals {
+			if !interval.StartedAt.Before(since) {
+				intervals = append(intervals, interval)
+			}
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].StartedAt.Before(*intervals[j].StartedAt) ||
+			(intervals[i].StartedAt.Equal(*intervals[j].StartedAt) && intervals[i].ID < intervals[j].ID)
+	})
+
+	for i, interval := range intervals {
+		if i == len(intervals)-1 {
+			if interval.FinishedAt != nil {
+				t.Errorf("last interval must be open: %+v", interval)
+			}
+			break
+		}
+		if interval.FinishedAt == nil {
+			t.Errorf("interval must be finished: %+v", interval)
+			continue
+		}
+		if next := intervals[i+1]; !interval.FinishedAt.Equal(*next.StartedAt) {
+			t.Errorf("interval %d must finish when interval %d starts: finished_at=%s, started_at=%s",
+				interval.ID, next.ID, interval.FinishedAt, next.StartedAt)
+		}
+	}
+}
+
+func newTestServer(t *testing.T, clock timeext.Clock) *httptest.Server {
+	t.Helper()
+
+	var (
+		storage              = db.NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+		conn                 = db.Lite(storage, db.Migrations(db.SQLite), db.NewSearchMigration())
+		usersService         = service.NewUsersService(repository.NewUsersRepository(conn, db.SQLite))
+		categoriesRepository = repository.NewCategoriesRepository(conn, db.SQLite)
+		categoriesService    = service.NewCategoriesService(categoriesRepository)
+		clientsService       = service.NewClientsService(repository.NewClientsRepository(conn, db.SQLite))
+		projectsService      = service.NewProjectsService(clientsService, repository.NewProjectsRepository(conn, db.SQLite))
+		activitiesRepository = repository.NewActivitiesRepository(conn, db.SQLite)
+		activitiesService    = service.NewActivitiesService(categoriesService, projectsService, activitiesRepository, nopObserver{}, time.UTC, clock)
+		reportsRepository    = repository.NewReportsRepository(conn, db.SQLite)
+		reportsService       = service.NewReportsService(reportsRepository, time.UTC, timeext.Rounding{}, clock)
+		searchService        = service.NewSearchService(repository.NewSearchRepository(conn, db.SQLite), time.UTC, clock)
+		router               = mux.NewRouter()
+	)
+
+	router.Use(middlewares.Users(usersService))
+
+	NewUsersHandler(usersService).Register(router)
+	NewCategoriesHandler(categoriesService).Register(router)
+	NewClientsHandler(clientsService).Register(router)
+	NewProjectsHandler(projectsService).Register(router)
+	NewActivitiesHandler(activitiesService).Register(router)
+	NewReportsHandler(reportsService).Register(router)
+	NewCalendarHandler(activitiesService, categoriesService).Register(router)
+	NewSearchHandler(searchService).Register(router)
+
+	server := httptest.NewServer(router)
+
+	t.Cleanup(func() {
+		server.Close()
+		activitiesRepository.Close()
+		ioext.Close(conn)
+	})
+
+	return server
+}
+
+func postActivity(t *testing.T, server *httptest.Server, description string) *types.Activity {
+	payload, err := json.Marshal(&types.Activity{CategoryID: 1, Description: description})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(server.URL+"/activities", httpext.MimeJSON, bytes.NewReader(payload))
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	return decodeActivity(t, res, http.StatusCreated)
+}
+
+func postManualActivity(t *testing.T, server *httptest.Server, categoryID int64, startedAt, finishedAt time.Time) *types.Activity {
+	payload, err := json.Marshal(&types.Activity{CategoryID: categoryID, StartedAt: &startedAt, FinishedAt: &finishedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(server.URL+"/activities/_/manual", httpext.MimeJSON, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decodeActivity(t, res, http.StatusCreated)
+}
+
+func putActivity(t *testing.T, server *httptest.Server, id int64, action string) *types.Activity {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/activities/%d/%s", server.URL, id, action), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	return decodeActivity(t, res, http.StatusOK)
+}
+
+func decodeActivity(t *testing.T, res *http.Response, status int) *types.Activity {
+	defer ioext.Close(res.Body)
+	if res.StatusCode != status {
+		t.Errorf("unexpected status: expected=%d, got=%d", status, res.StatusCode)
+		return nil
+	}
+	if status >= http.StatusBadRequest {
+		decodeProblem(t, res, status)
+		return nil
+	}
+	activity := new(types.Activity)
+	if err := json.NewDecoder(res.Body).Decode(activity); err != nil {
+		t.Error(err)
+	}
+	return activity
+}
+
+func getActivities(t *testing.T, server *httptest.Server) []*types.Activity {
+	t.Helper()
+
+	return listActivities(t, server, "/activities")
+}
+
+// listActivities gets the activities listed by path, e.g. a filter.
+func listActivities(t *testing.T, server *httptest.Server, path string) []*types.Activity {
+	t.Helper()
+
+	res, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status of %s: expected=%d, got=%d", path, http.StatusOK, res.StatusCode)
+	}
+	var activities []*types.Activity
+	if err = json.NewDecoder(res.Body).Decode(&activities); err != nil {
+		t.Fatal(err)
+	}
+	return activities
+}
+
+type nopObserver struct{}
+
+func (nopObserver) Count(string, string) {}
+
+func (nopObserver) DurationOf(string, string, time.Duration) {}
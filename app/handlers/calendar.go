@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ical"
+	"github.com/ungame/timetrack/queries"
+	"log"
+	"net/http"
+)
+
+type calendarHandler struct {
+	activitiesService service.ActivitiesService
+	categoriesService service.CategoriesService
+}
+
+func NewCalendarHandler(activitiesService service.ActivitiesService, categoriesService service.CategoriesService) Handler {
+	return &calendarHandler{
+		activitiesService: activitiesService,
+		categoriesService: categoriesService,
+	}
+}
+
+func (h *calendarHandler) Register(router *mux.Router) {
+	router.Path("/calendar.ics").HandlerFunc(h.GetCalendar).Methods(http.MethodGet)
+}
+
+// GetCalendar writes the finished activities of the period as an iCalendar
+// feed, with an event per activity. The period is set as in the activities
+// filter and defaults to the last 30 days, which suits calendar
+// subscriptions. Unlike the activities filter, the feed is not limited, so
+// that calendars get every activity of the period.
+func (h *calendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	filter, err := periodFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	filter.OrderBy = queries.Desc.String()
+	query := r.URL.Query()
+	if query.Get("period") == "" && query.Get("from") == "" && query.Get("to") == "" {
+		filter.PeriodName = queries.Monthly.String()
+	}
+
+	activities, err := h.activitiesService.FilterActivitiesByPeriod(r.Context(), middlewares.UserID(r), filter)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	calendar := &ical.Calendar{
+		ProdID: "-//ungame//timetrack//EN",
+		Name:   "timetrack",
+		Events: make([]*ical.Event, 0, len(activities)),
+	}
+	for _, activity := range activities {
+		if activity.Status != models.Finished.String() || activity.StartedAt == nil || activity.FinishedAt == nil {
+			continue
+		}
+
+		event := &ical.Event{
+			UID:         fmt.Sprintf("activity-%d@timetrack", activity.ID),
+			Stamp:       *activity.FinishedAt,
+			Start:       *activity.StartedAt,
+			End:         *activity.FinishedAt,
+			Summary:     activity.Description,
+			Description: activity.Description,
+		}
+		if activity.UpdatedAt != nil {
+			event.Stamp = *activity.UpdatedAt
+		}
+
+		category, err := h.categoriesService.GetCategory(r.Context(), activity.CategoryID)
+		if err != nil {
+			log.Println("unable to get category:", err.Error())
+		} else {
+			event.Categories = append(event.Categories, category.Name)
+			event.Summary = "[" + category.Name + "]"
+			if activity.Description != "" {
+				event.Summary += " " + activity.Description
+			}
+		}
+		event.Categories = append(event.Categories, activity.Tags...)
+
+		calendar.Events = append(calendar.Events, event)
+	}
+
+	w.Header().Set(httpext.HeaderContentType, httpext.MimeICS+"; charset=utf-8")
+	w.Header().Set(httpext.HeaderContentDisposition, `inline; filename="timetrack.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err = calendar.WriteTo(w); err != nil {
+		log.Println("write calendar failed with error:", err.Error())
+	}
+}
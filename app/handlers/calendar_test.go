@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarHandler_GetCalendar(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		server = newTestServer(t, timeext.NewFrozenClock(today.Add(12*time.Hour)))
+	)
+
+	meeting := postManualActivity(t, server, 1, today.Add(9*time.Hour), today.Add(10*time.Hour))
+	result := postImport(t, server, httpext.MimeNDJSON,
+		`{"category":"coding","description":"api, docs; and a description long enough to be folded\nin two lines","started_at":"2022-11-01T10:00:00Z","finished_at":"2022-11-01T11:30:00Z"}`,
+		false)
+	coding := result.Rows[0].ID
+	postActivity(t, server, "running")
+	postManualActivity(t, server, 1, today.Add(-40*24*time.Hour), today.Add(-40*24*time.Hour+time.Hour))
+
+	res, err := http.Get(server.URL + "/calendar.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get(httpext.HeaderContentType), httpext.MimeICS) {
+		t.Fatalf("unexpected response: status=%d, content-type=%s", res.StatusCode, res.Header.Get(httpext.HeaderContentType))
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\r\n"), "\r\n")
+	for _, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	// unfolded, events are listed from the latest activity
+	content := strings.ReplaceAll(string(body), "\r\n ", "")
+	events := []string{
+		"BEGIN:VEVENT\r\n" +
+			fmt.Sprintf("UID:activity-%d@timetrack\r\n", coding) +
+			"DTSTAMP:20221101T120000Z\r\n" +
+			"DTSTART:20221101T100000Z\r\n" +
+			"DTEND:20221101T113000Z\r\n" +
+			`SUMMARY:[coding] api\, docs\; and a description long enough to be folded\nin two lines` + "\r\n" +
+			`DESCRIPTION:api\, docs\; and a description long enough to be folded\nin two lines` + "\r\n" +
+			"CATEGORIES:coding\r\n" +
+			"END:VEVENT\r\n",
+		"BEGIN:VEVENT\r\n" +
+			fmt.Sprintf("UID:activity-%d@timetrack\r\n", meeting.ID) +
+			"DTSTAMP:20221101T120000Z\r\n" +
+			"DTSTART:20221101T090000Z\r\n" +
+			"DTEND:20221101T100000Z\r\n" +
+			"SUMMARY:[meeting]\r\n" +
+			"CATEGORIES:meeting\r\n" +
+			"END:VEVENT\r\n",
+	}
+	expected := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//ungame//timetrack//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"METHOD:PUBLISH\r\n" +
+		"X-WR-CALNAME:timetrack\r\n" +
+		strings.Join(events, "") +
+		"END:VCALENDAR\r\n"
+	if content != expected {
+		t.Errorf("unexpected calendar: \nexpected=%q \ngot=%q", expected, content)
+	}
+
+	// the feed has every activity of the period, whatever the limit
+	res, err = http.Get(server.URL + "/calendar.ics?limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+	body, err = io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(body), "BEGIN:VEVENT"); n != len(events) {
+		t.Errorf("unexpected events: expected=%d, got=%d", len(events), n)
+	}
+}
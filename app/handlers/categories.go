@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/problem"
 	"github.com/ungame/timetrack/app/service"
 	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"io"
 	"net/http"
 	"strconv"
 )
@@ -18,19 +23,43 @@ func NewCategoriesHandler(categoriesService service.CategoriesService) Handler {
 
 func (c *categoriesHandler) Register(router *mux.Router) {
 	router.Path("/categories/{id}").HandlerFunc(c.GetCategory).Methods(http.MethodGet)
+	router.Path("/categories/{id}").HandlerFunc(c.PutCategory).Methods(http.MethodPut)
+	router.Path("/categories/{id}").HandlerFunc(c.DeleteCategory).Methods(http.MethodDelete)
+	router.Path("/categories").HandlerFunc(c.PostCategory).Methods(http.MethodPost)
 	router.Path("/categories").HandlerFunc(c.GetCategories).Methods(http.MethodGet)
 }
 
+func (c *categoriesHandler) PostCategory(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Category)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	category, err := c.categoriesService.CreateCategory(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, category.ID))
+	httpext.WriteJson(w, http.StatusCreated, category)
+}
+
 func (c *categoriesHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
 		return
 	}
 	category, err := c.categoriesService.GetCategory(r.Context(), id)
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, err)
 		return
 	}
 	httpext.WriteJson(w, http.StatusOK, category)
@@ -39,8 +68,61 @@ func (c *categoriesHandler) GetCategory(w http.ResponseWriter, r *http.Request)
 func (c *categoriesHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
 	categories, err := c.categoriesService.GetCategories(r.Context())
 	if err != nil {
-		httpext.WriteError(w, http.StatusBadRequest, err)
+		problem.Write(w, r, err)
 		return
 	}
 	httpext.WriteJson(w, http.StatusOK, categories)
 }
+
+func (c *categoriesHandler) PutCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Category)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input.ID = id
+	category, err := c.categoriesService.UpdateCategory(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, category)
+}
+
+// DeleteCategory accepts an optional reassign_to query parameter with the id
+// of the category that should receive the activities of the deleted one.
+func (c *categoriesHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	var replacementID int64
+	if value := r.URL.Query().Get("reassign_to"); value != "" {
+		replacementID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			problem.Write(w, r, invalidParam("reassign_to", "must be an integer"))
+			return
+		}
+	}
+	err = c.categoriesService.DeleteCategory(r.Context(), id, replacementID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderEntity, fmt.Sprint(id))
+	w.WriteHeader(http.StatusNoContent)
+}
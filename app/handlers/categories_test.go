@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCategoriesHandler_Categories(t *testing.T) {
+	server := newTestServer(t, timeext.NewSystemClock())
+
+	design := new(types.Category)
+	send(t, server, http.MethodPost, "/categories", &types.Category{Name: " Design ", HourlyRate: 9000}, http.StatusCreated, design)
+	if design.Name != "design" || design.HourlyRate != 9000 {
+		t.Fatalf("unexpected category: %+v", design)
+	}
+	send(t, server, http.MethodPost, "/categories", &types.Category{Name: "DESIGN"}, http.StatusConflict, nil)
+	send(t, server, http.MethodPost, "/categories", &types.Category{Name: " "}, http.StatusUnprocessableEntity, nil)
+	send(t, server, http.MethodPost, "/categories", &types.Category{Name: "audit", HourlyRate: -1}, http.StatusUnprocessableEntity, nil)
+
+	path := fmt.Sprintf("/categories/%d", design.ID)
+	send(t, server, http.MethodPut, path, &types.Category{Name: "ux"}, http.StatusOK, design)
+	if design.Name != "ux" || design.HourlyRate != 0 {
+		t.Errorf("update must replace the category: %+v", design)
+	}
+	send(t, server, http.MethodPut, path, &types.Category{Name: "coding"}, http.StatusConflict, nil)
+	send(t, server, http.MethodPut, "/categories/404", &types.Category{Name: "missing"}, http.StatusNotFound, nil)
+
+	got := new(types.Category)
+	send(t, server, http.MethodGet, path, nil, http.StatusOK, got)
+	if got.Name != "ux" {
+		t.Errorf("unexpected category: %+v", got)
+	}
+
+	today := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	activity := postManualActivity(t, server, design.ID, today, today.Add(time.Hour))
+
+	send(t, server, http.MethodDelete, path, nil, http.StatusConflict, nil)
+	send(t, server, http.MethodDelete, path+"?reassign_to=abc", nil, http.StatusBadRequest, nil)
+	send(t, server, http.MethodDelete, fmt.Sprintf("%s?reassign_to=%d", path, design.ID), nil, http.StatusUnprocessableEntity, nil)
+	send(t, server, http.MethodDelete, path+"?reassign_to=404", nil, http.StatusUnprocessableEntity, nil)
+	send(t, server, http.MethodDelete, path+"?reassign_to=2", nil, http.StatusNoContent, nil)
+
+	send(t, server, http.MethodGet, path, nil, http.StatusNotFound, nil)
+	send(t, server, http.MethodGet, fmt.Sprintf("/activities/%d", activity.ID), nil, http.StatusOK, activity)
+	if activity.CategoryID != 2 {
+		t.Errorf("activities must be reassigned: expected=2, got=%d", activity.CategoryID)
+	}
+
+	send(t, server, http.MethodDelete, "/categories/4", nil, http.StatusNoContent, nil)
+	send(t, server, http.MethodDelete, "/categories/4", nil, http.StatusNotFound, nil)
+}
+
+func TestCategoriesHandler_PostCategoryInParallel(t *testing.T) {
+	server := newTestServer(t, timeext.NewSystemClock())
+
+	var (
+		wg       sync.WaitGroup
+		statuses = make(chan int, parallelRequests)
+	)
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- postStatus(t, server, "/categories", &types.Category{Name: "design"})
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	count := make(map[int]int)
+	for status := range statuses {
+		count[status]++
+	}
+	if count[http.StatusCreated] != 1 || count[http.StatusConflict] != parallelRequests-1 {
+		t.Errorf("unexpected statuses: %v", count)
+	}
+}
+
+// TestCategoriesHandler_PostCategoryNotCached checks that a name taken by a
+// category missing from the cache, e.g. created by another instance of the
+// server, is a conflict.
+func TestCategoriesHandler_PostCategoryNotCached(t *testing.T) {
+	var (
+		storage    = db.NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+		conn       = db.Lite(storage, db.Migrations(db.SQLite))
+		stale      = service.NewCategoriesService(repository.NewCategoriesRepository(conn, db.SQLite))
+		categories = service.NewCategoriesService(repository.NewCategoriesRepository(conn, db.SQLite))
+		router     = mux.NewRouter()
+	)
+	NewCategoriesHandler(stale).Register(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		server.Close()
+		ioext.Close(conn)
+	})
+
+	if _, err := categories.CreateCategory(context.Background(), &types.Category{Name: "design"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.Post(server.URL+"/categories", httpext.MimeJSON, bytes.NewReader([]byte(`{"name":"design"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+	if problem := decodeProblem(t, res, http.StatusConflict); problem.Code != "category_exists" {
+		t.Errorf("unexpected code: %s", problem.Code)
+	}
+}
+
+// postStatus posts the input as JSON and returns the status of the response.
+// Unlike send, it may be called by other goroutines than the test one.
+func postStatus(t *testing.T, server *httptest.Server, path string, input any) int {
+	return sendStatus(t, server, http.MethodPost, path, input)
+}
+
+// sendStatus sends the input as JSON and returns the status of the response,
+// as postStatus does.
+func sendStatus(t *testing.T, server *httptest.Server, method, path string, input any) int {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(payload))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req.Header.Set(httpext.HeaderContentType, httpext.MimeJSON)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer ioext.Close(res.Body)
+	return res.StatusCode
+}
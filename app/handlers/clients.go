@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type clientsHandler struct {
+	clientsService service.ClientsService
+}
+
+func NewClientsHandler(clientsService service.ClientsService) Handler {
+	return &clientsHandler{clientsService: clientsService}
+}
+
+func (c *clientsHandler) Register(router *mux.Router) {
+	router.Path("/clients/{id}").HandlerFunc(c.GetClient).Methods(http.MethodGet)
+	router.Path("/clients/{id}").HandlerFunc(c.PutClient).Methods(http.MethodPut)
+	router.Path("/clients/{id}").HandlerFunc(c.DeleteClient).Methods(http.MethodDelete)
+	router.Path("/clients").HandlerFunc(c.PostClient).Methods(http.MethodPost)
+	router.Path("/clients").HandlerFunc(c.GetClients).Methods(http.MethodGet)
+}
+
+func (c *clientsHandler) PostClient(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Client)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	client, err := c.clientsService.CreateClient(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, client.ID))
+	httpext.WriteJson(w, http.StatusCreated, client)
+}
+
+func (c *clientsHandler) GetClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	client, err := c.clientsService.GetClient(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, client)
+}
+
+func (c *clientsHandler) GetClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := c.clientsService.GetClients(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, clients)
+}
+
+func (c *clientsHandler) PutClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Client)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input.ID = id
+	client, err := c.clientsService.UpdateClient(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, client)
+}
+
+// DeleteClient accepts an optional reassign_to query parameter with the id
+// of the client that should receive the activities of the deleted one.
+func (c *clientsHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	err = c.clientsService.DeleteClient(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderEntity, fmt.Sprint(id))
+	w.WriteHeader(http.StatusNoContent)
+}
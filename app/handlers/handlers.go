@@ -1,7 +1,119 @@
 package handlers
 
-import "github.com/gorilla/mux"
+import (
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/queries"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"strconv"
+)
 
 type Handler interface {
 	Register(router *mux.Router)
 }
+
+var ErrUnsupportedMediaType = service.NewError(service.KindUnsupportedMediaType, "unsupported_media_type", "unsupported media type")
+
+// invalidParam returns the error of a query parameter or path variable of the
+// request that can't be parsed.
+func invalidParam(name, message string) error {
+	return &service.FieldError{Err: service.ErrBadRequest, Field: name, Message: message}
+}
+
+// timezoneOf returns the timezone of the tz query parameter, or else of the
+// X-Timezone header.
+func timezoneOf(r *http.Request) string {
+	if timezone := r.URL.Query().Get("tz"); timezone != "" {
+		return timezone
+	}
+	return r.Header.Get(httpext.HeaderTimezone)
+}
+
+// filterOf reads the tag, project_id and client_id query parameters of the
+// request.
+func filterOf(r *http.Request) (*types.ActivityFilter, error) {
+	var (
+		query  = r.URL.Query()
+		filter = &types.ActivityFilter{Tag: query.Get("tag")}
+		err    error
+	)
+
+	if query.Get("project_id") != "" {
+		filter.ProjectID, err = strconv.ParseInt(query.Get("project_id"), 10, 64)
+		if err != nil {
+			return nil, invalidParam("project_id", "must be an integer")
+		}
+	}
+
+	if query.Get("client_id") != "" {
+		filter.ClientID, err = strconv.ParseInt(query.Get("client_id"), 10, 64)
+		if err != nil {
+			return nil, invalidParam("client_id", "must be an integer")
+		}
+	}
+
+	return filter, nil
+}
+
+// pageOf reads the after_id and page_size query parameters of the request.
+func pageOf(r *http.Request) (*types.Page, error) {
+	var (
+		query = r.URL.Query()
+		page  = new(types.Page)
+		err   error
+	)
+
+	if query.Get("after_id") != "" {
+		page.AfterID, err = strconv.ParseInt(query.Get("after_id"), 10, 64)
+		if err != nil {
+			return nil, invalidParam("after_id", "must be an integer")
+		}
+	}
+
+	if query.Get("page_size") != "" {
+		page.Size, err = strconv.Atoi(query.Get("page_size"))
+		if err != nil {
+			return nil, invalidParam("page_size", "must be an integer")
+		}
+	}
+
+	return page, nil
+}
+
+// nextPageURI returns the uri of the request for the next page, keeping the
+// other query parameters.
+func nextPageURI(r *http.Request, next *types.Page) string {
+	query := r.URL.Query()
+	query.Set("after_id", strconv.FormatInt(next.AfterID, 10))
+	query.Set("page_size", strconv.Itoa(next.Size))
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// periodFilterOf reads the period, from, to, tz and week_start query
+// parameters of the request, along with the ones of filterOf. The timezone
+// may also be sent in the X-Timezone header, and the period defaults to
+// today.
+func periodFilterOf(r *http.Request) (*types.PeriodFilter, error) {
+	activityFilter, err := filterOf(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := &types.PeriodFilter{
+		PeriodName:     query.Get("period"),
+		From:           query.Get("from"),
+		To:             query.Get("to"),
+		Timezone:       timezoneOf(r),
+		WeekStart:      query.Get("week_start"),
+		ActivityFilter: *activityFilter,
+	}
+
+	if filter.PeriodName == "" && filter.From == "" && filter.To == "" {
+		filter.PeriodName = queries.Today.String()
+	}
+
+	return filter, nil
+}
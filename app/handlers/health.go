@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Pinger checks the connection to the database, such as a *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type healthHandler struct {
+	pinger  Pinger
+	timeout time.Duration
+}
+
+// NewHealthHandler creates the handler of the health probes, whose readiness
+// probe pings the database within timeout.
+func NewHealthHandler(pinger Pinger, timeout time.Duration) Handler {
+	return &healthHandler{pinger: pinger, timeout: timeout}
+}
+
+func (h *healthHandler) Register(router *mux.Router) {
+	router.Path("/healthz").HandlerFunc(h.Live).Methods(http.MethodGet, http.MethodHead)
+	router.Path("/readyz").HandlerFunc(h.Ready).Methods(http.MethodGet, http.MethodHead)
+}
+
+// Live responds while the server is up.
+func (h *healthHandler) Live(w http.ResponseWriter, _ *http.Request) {
+	httpext.WriteJson(w, http.StatusOK, &types.Health{Status: types.HealthOK})
+}
+
+// Ready responds whether the server can reach the database. Ping errors are
+// logged rather than responded, since the probe is usually unauthenticated.
+func (h *healthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.pinger.PingContext(ctx); err != nil {
+		log.Println("ping database failed with error:", err.Error())
+		httpext.WriteJson(w, http.StatusServiceUnavailable, &types.Health{Status: types.HealthUnavailable})
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, &types.Health{Status: types.HealthOK})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/ioext"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealthHandler_Ready(t *testing.T) {
+	var (
+		pingErr error
+		router  = mux.NewRouter()
+	)
+	NewHealthHandler(pingerFunc(func(context.Context) error { return pingErr }), time.Second).Register(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ready := func() (int, string) {
+		res, err := http.Get(server.URL + "/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ioext.Close(res.Body)
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, strings.TrimSpace(string(body))
+	}
+
+	if status, body := ready(); status != http.StatusOK || body != `{"status":"ok"}` {
+		t.Errorf("unexpected response: status=%d, body=%s", status, body)
+	}
+
+	// the ping error, which may name hosts or users, is only logged
+	pingErr = errors.New("dial tcp db.internal:3306: access denied for user timetrack")
+	if status, body := ready(); status != http.StatusServiceUnavailable || body != `{"status":"unavailable"}` {
+		t.Errorf("unexpected response: status=%d, body=%s", status, body)
+	}
+}
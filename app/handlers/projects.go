@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type projectsHandler struct {
+	projectsService service.ProjectsService
+}
+
+func NewProjectsHandler(projectsService service.ProjectsService) Handler {
+	return &projectsHandler{projectsService: projectsService}
+}
+
+func (p *projectsHandler) Register(router *mux.Router) {
+	router.Path("/projects/{id}").HandlerFunc(p.GetProject).Methods(http.MethodGet)
+	router.Path("/projects/{id}").HandlerFunc(p.PutProject).Methods(http.MethodPut)
+	router.Path("/projects/{id}").HandlerFunc(p.DeleteProject).Methods(http.MethodDelete)
+	router.Path("/projects").HandlerFunc(p.PostProject).Methods(http.MethodPost)
+	router.Path("/projects").HandlerFunc(p.GetProjects).Methods(http.MethodGet)
+}
+
+func (p *projectsHandler) PostProject(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Project)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	project, err := p.projectsService.CreateProject(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, project.ID))
+	httpext.WriteJson(w, http.StatusCreated, project)
+}
+
+func (p *projectsHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	project, err := p.projectsService.GetProject(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, project)
+}
+
+// GetProjects accepts an optional client_id query parameter to list only the
+// projects of the client.
+func (p *projectsHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
+	var (
+		clientID int64
+		err      error
+	)
+	if value := r.URL.Query().Get("client_id"); value != "" {
+		clientID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			problem.Write(w, r, invalidParam("client_id", "must be an integer"))
+			return
+		}
+	}
+	projects, err := p.projectsService.GetProjects(r.Context(), clientID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, projects)
+}
+
+func (p *projectsHandler) PutProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Project)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input.ID = id
+	project, err := p.projectsService.UpdateProject(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, project)
+}
+
+// DeleteProject accepts an optional reassign_to query parameter with the id
+// of the project that should receive the activities of the deleted one.
+func (p *projectsHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	err = p.projectsService.DeleteProject(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderEntity, fmt.Sprint(id))
+	w.WriteHeader(http.StatusNoContent)
+}
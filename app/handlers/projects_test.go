@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectsHandler_Projects(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(12 * time.Hour))
+		server = newTestServer(t, clock)
+	)
+
+	acme := new(types.Client)
+	send(t, server, http.MethodPost, "/clients", &types.Client{Name: "acme"}, http.StatusCreated, acme)
+	send(t, server, http.MethodPost, "/clients", &types.Client{Name: "ACME"}, http.StatusConflict, nil)
+
+	website := new(types.Project)
+	send(t, server, http.MethodPost, "/projects", &types.Project{ClientID: acme.ID, Name: "website"}, http.StatusCreated, website)
+	internal := new(types.Project)
+	send(t, server, http.MethodPost, "/projects", &types.Project{Name: "internal"}, http.StatusCreated, internal)
+	send(t, server, http.MethodPost, "/projects", &types.Project{Name: "Website"}, http.StatusConflict, nil)
+	send(t, server, http.MethodPost, "/projects", &types.Project{ClientID: 404, Name: "orphan"}, http.StatusUnprocessableEntity, nil)
+
+	var projects []*types.Project
+	send(t, server, http.MethodGet, fmt.Sprintf("/projects?client_id=%d", acme.ID), nil, http.StatusOK, &projects)
+	if len(projects) != 1 || projects[0].ID != website.ID {
+		t.Fatalf("unexpected projects of client %d: %+v", acme.ID, projects)
+	}
+
+	startedAt, finishedAt := today.Add(9*time.Hour), today.Add(10*time.Hour)
+	activity := new(types.Activity)
+	send(t, server, http.MethodPost, "/activities/_/manual", &types.Activity{
+		CategoryID: 2,
+		ProjectID:  pointer.New(website.ID),
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+	}, http.StatusCreated, activity)
+	if activity.ProjectID == nil || *activity.ProjectID != website.ID {
+		t.Fatalf("unexpected project of activity: expected=%d, got=%v", website.ID, activity.ProjectID)
+	}
+	postActivity(t, server, "running")
+	send(t, server, http.MethodPost, "/activities", &types.Activity{CategoryID: 2, ProjectID: pointer.New(int64(404))}, http.StatusUnprocessableEntity, nil)
+
+	for path, expected := range map[string]int{
+		fmt.Sprintf("/activities?project_id=%d", website.ID):                   1,
+		fmt.Sprintf("/activities?project_id=%d", internal.ID):                  0,
+		fmt.Sprintf("/activities?client_id=%d", acme.ID):                       1,
+		fmt.Sprintf("/activities/_/filter?period=today&client_id=%d", acme.ID): 1,
+		fmt.Sprintf("/activities/_/filter?period=today&project_id=%d", 404):    0,
+		"/activities/_/filter?period=today":                                    2,
+	} {
+		if activities := listActivities(t, server, path); len(activities) != expected {
+			t.Errorf("unexpected activities of %s: expected=%d, got=%d", path, expected, len(activities))
+		}
+	}
+
+	summary := getSummary(t, server, fmt.Sprintf("period=today&project_id=%d", website.ID))
+	if summary.Count != 1 || summary.Duration != 3600 {
+		t.Errorf("unexpected summary of project %d: %+v", website.ID, summary)
+	}
+
+	send(t, server, http.MethodDelete, fmt.Sprintf("/clients/%d", acme.ID), nil, http.StatusConflict, nil)
+	send(t, server, http.MethodDelete, fmt.Sprintf("/projects/%d", website.ID), nil, http.StatusConflict, nil)
+	send(t, server, http.MethodPut, fmt.Sprintf("/projects/%d", website.ID), &types.Project{Name: "site"}, http.StatusOK, website)
+	if website.Name != "site" || website.ClientID != acme.ID {
+		t.Errorf("unexpected updated project: %+v", website)
+	}
+
+	// updates without a project keep it, and a project of 0 removes it
+	path := fmt.Sprintf("/activities/%d", activity.ID)
+	send(t, server, http.MethodPut, path, &types.Activity{Billable: pointer.New(true)}, http.StatusOK, activity)
+	if activity.ProjectID == nil || *activity.ProjectID != website.ID {
+		t.Fatalf("update without a project must keep it: expected=%d, got=%v", website.ID, activity.ProjectID)
+	}
+	send(t, server, http.MethodPut, path, &types.Activity{ProjectID: pointer.New(int64(404))}, http.StatusUnprocessableEntity, nil)
+	activity = new(types.Activity)
+	send(t, server, http.MethodPut, path, &types.Activity{ProjectID: pointer.New(int64(0))}, http.StatusOK, activity)
+	if activity.ProjectID != nil {
+		t.Fatalf("update with a project of 0 must remove it: got=%d", *activity.ProjectID)
+	}
+	assertChanges(t, server, activity.ID, []string{
+		"billable: false -> true",
+		fmt.Sprintf("project_id: %d -> <nil>", website.ID),
+	})
+	send(t, server, http.MethodDelete, fmt.Sprintf("/projects/%d", website.ID), nil, http.StatusNoContent, nil)
+	send(t, server, http.MethodDelete, fmt.Sprintf("/projects/%d", internal.ID), nil, http.StatusNoContent, nil)
+	send(t, server, http.MethodGet, fmt.Sprintf("/projects/%d", internal.ID), nil, http.StatusNotFound, nil)
+}
+
+// send sends the input as JSON, checks the status of the response and decodes
+// it into output unless it is nil.
+func send(t *testing.T, server *httptest.Server, method, path string, input any, status int, output any) {
+	t.Helper()
+
+	sendAs(t, server, "", method, path, input, status, output)
+}
+
+// sendAs sends the request as the user named in the X-User header, or as the
+// default user if it is empty.
+func sendAs(t *testing.T, server *httptest.Server, user, method, path string, input any, status int, output any) {
+	t.Helper()
+
+	var body io.Reader
+	if input != nil {
+		payload, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, server.URL+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(httpext.HeaderContentType, httpext.MimeJSON)
+	if user != "" {
+		req.Header.Set(httpext.HeaderUser, user)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != status {
+		payload, _ := io.ReadAll(res.Body)
+		t.Fatalf("unexpected status of %s %s: expected=%d, got=%d, body=%s", method, path, status, res.StatusCode, payload)
+	}
+	if output != nil {
+		if err = json.NewDecoder(res.Body).Decode(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestProjectsHandler_PostNotCached checks that names taken by clients and
+// projects missing from the caches, e.g. created by another instance of the
+// server, are conflicts.
+func TestProjectsHandler_PostNotCached(t *testing.T) {
+	var (
+		storage  = db.NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+		conn     = db.Lite(storage, db.Migrations(db.SQLite))
+		clients  = service.NewClientsService(repository.NewClientsRepository(conn, db.SQLite))
+		projects = service.NewProjectsService(clients, repository.NewProjectsRepository(conn, db.SQLite))
+		stale    = service.NewClientsService(repository.NewClientsRepository(conn, db.SQLite))
+		router   = mux.NewRouter()
+	)
+	NewClientsHandler(stale).Register(router)
+	NewProjectsHandler(service.NewProjectsService(stale, repository.NewProjectsRepository(conn, db.SQLite))).Register(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		server.Close()
+		ioext.Close(conn)
+	})
+
+	ctx := context.Background()
+	if _, err := clients.CreateClient(ctx, &types.Client{Name: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projects.CreateProject(ctx, &types.Project{Name: "website"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, body := range map[string]string{
+		"/clients":  `{"name":"acme"}`,
+		"/projects": `{"name":"website"}`,
+	} {
+		res, err := http.Post(server.URL+path, httpext.MimeJSON, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		problem := decodeProblem(t, res, http.StatusConflict)
+		ioext.Close(res.Body)
+		if expected := strings.TrimSuffix(path[1:], "s") + "_exists"; problem.Code != expected {
+			t.Errorf("unexpected code of %s: expected=%s, got=%s", path, expected, problem.Code)
+		}
+	}
+}
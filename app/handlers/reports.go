@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+)
+
+type reportsHandler struct {
+	reportsService service.ReportsService
+}
+
+func NewReportsHandler(reportsService service.ReportsService) Handler {
+	return &reportsHandler{reportsService: reportsService}
+}
+
+func (h *reportsHandler) Register(router *mux.Router) {
+	router.Path("/reports/summary").HandlerFunc(h.GetSummary).Methods(http.MethodGet)
+	router.Path("/reports/billing").HandlerFunc(h.GetBilling).Methods(http.MethodGet)
+}
+
+func (h *reportsHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	filter, err := periodFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	summary, err := h.reportsService.Summary(r.Context(), middlewares.UserID(r), filter)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, summary)
+}
+
+// GetBilling accepts the query parameters of the summary along with an
+// optional rounding rule, e.g. rounding=up:15m.
+func (h *reportsHandler) GetBilling(w http.ResponseWriter, r *http.Request) {
+	filter, err := periodFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	billing, err := h.reportsService.Billing(r.Context(), middlewares.UserID(r), &types.BillingFilter{
+		PeriodFilter: *filter,
+		Rounding:     r.URL.Query().Get("rounding"),
+	})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, billing)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReportsHandler_GetSummary(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(12 * time.Hour))
+		server = newTestServer(t, clock)
+	)
+
+	postManualActivity(t, server, 1, today.Add(-15*time.Hour), today.Add(-14*time.Hour))
+	postManualActivity(t, server, 1, today.Add(9*time.Hour), today.Add(10*time.Hour))
+	postManualActivity(t, server, 2, today.Add(10*time.Hour), today.Add(10*time.Hour+30*time.Minute))
+	running := postActivity(t, server, "running")
+	clock.Add(15 * time.Minute)
+
+	summary := getSummary(t, server, "period=today")
+	if summary.Count != 3 || summary.Duration != 6300 {
+		t.Errorf("unexpected total: count=%d, duration=%d", summary.Count, summary.Duration)
+	}
+	expected := []*types.CategorySummary{
+		{CategoryID: running.CategoryID, Category: "meeting", Count: 2, Duration: 4500, Share: 0.7143},
+		{CategoryID: 2, Category: "coding", Count: 1, Duration: 1800, Share: 0.2857},
+	}
+	assertCategorySummaries(t, summary.Categories, expected)
+	if len(summary.Days) != 1 || summary.Days[0].Date != "2022-11-01" {
+		t.Fatalf("unexpected days: %+v", summary.Days)
+	}
+	assertCategorySummaries(t, summary.Days[0].Categories, expected)
+
+	// 2022-10-31 09:00 UTC is still the 31st in UTC-3, but 2022-11-01 00:00
+	// UTC is not.
+	summary = getSummary(t, server, "period=this_week&tz=America/Sao_Paulo")
+	if summary.Count != 4 || summary.Duration != 9900 {
+		t.Errorf("unexpected total: count=%d, duration=%d", summary.Count, summary.Duration)
+	}
+	if len(summary.Days) != 2 || summary.Days[0].Date != "2022-10-31" || summary.Days[0].Duration != 3600 {
+		t.Errorf("unexpected days: %+v", summary.Days)
+	}
+}
+
+// TestReportsHandler_GetSummaryAcrossDST checks that the days of a period in
+// which the timezone changes its offset are the local dates of the activities.
+func TestReportsHandler_GetSummaryAcrossDST(t *testing.T) {
+	var (
+		clock  = timeext.NewFrozenClock(time.Date(2022, time.November, 9, 12, 0, 0, 0, time.UTC))
+		server = newTestServer(t, clock)
+	)
+
+	// New York is UTC-4 until 2022-11-06 06:00 UTC and UTC-5 after it.
+	startedAt := time.Date(2022, time.October, 31, 4, 30, 0, 0, time.UTC)
+	postManualActivity(t, server, 1, startedAt, startedAt.Add(15*time.Minute))
+	startedAt = time.Date(2022, time.November, 7, 4, 30, 0, 0, time.UTC)
+	postManualActivity(t, server, 1, startedAt, startedAt.Add(30*time.Minute))
+
+	summary := getSummary(t, server, "period=last_week&tz=America/New_York")
+	if summary.Count != 2 || summary.Duration != 2700 {
+		t.Errorf("unexpected total: count=%d, duration=%d", summary.Count, summary.Duration)
+	}
+	if len(summary.Days) != 2 {
+		t.Fatalf("unexpected days: %+v", summary.Days)
+	}
+	for i, expected := range []string{"2022-10-31", "2022-11-06"} {
+		if summary.Days[i].Date != expected {
+			t.Errorf("unexpected date of day %d: expected=%s, got=%s", i, expected, summary.Days[i].Date)
+		}
+	}
+}
+
+func TestReportsHandler_GetBilling(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(12 * time.Hour))
+		server = newTestServer(t, clock)
+	)
+
+	send(t, server, http.MethodPut, "/categories/1", &types.Category{Name: "meeting", HourlyRate: 10000}, http.StatusOK, nil)
+	send(t, server, http.MethodPut, "/categories/2", &types.Category{Name: "coding", HourlyRate: 8000}, http.StatusOK, nil)
+	send(t, server, http.MethodPut, "/categories/3", &types.Category{Name: "review", HourlyRate: -1}, http.StatusUnprocessableEntity, nil)
+
+	add := func(categoryID int64, from, to time.Duration, billable bool, hourlyRate *int64) *types.Activity {
+		var (
+			startedAt  = today.Add(from)
+			finishedAt = today.Add(to)
+			activity   = new(types.Activity)
+		)
+		send(t, server, http.MethodPost, "/activities/_/manual", &types.Activity{
+			CategoryID: categoryID,
+			Billable:   pointer.New(billable),
+			HourlyRate: hourlyRate,
+			StartedAt:  &startedAt,
+			FinishedAt: &finishedAt,
+		}, http.StatusCreated, activity)
+		return activity
+	}
+	add(1, 9*time.Hour, 9*time.Hour+50*time.Minute, true, nil)
+	add(2, 10*time.Hour, 10*time.Hour+20*time.Minute, true, pointer.New(int64(12000)))
+	add(2, 10*time.Hour+30*time.Minute, 10*time.Hour+40*time.Minute, true, nil)
+	unbilled := add(2, 11*time.Hour, 11*time.Hour+30*time.Minute, false, nil)
+	send(t, server, http.MethodPost, "/activities", &types.Activity{CategoryID: 2, Billable: pointer.New(true)}, http.StatusCreated, nil)
+	send(t, server, http.MethodPost, "/activities/_/manual", &types.Activity{CategoryID: 2, HourlyRate: pointer.New(int64(-1))}, http.StatusUnprocessableEntity, nil)
+	clock.Add(time.Hour)
+
+	billing := getBilling(t, server, "period=today&rounding=up:15m")
+	if billing.Rounding != "up:15m" || billing.Count != 3 || billing.Duration != 6300 || billing.Hours != 1.75 || billing.Amount != 18000 {
+		t.Errorf("unexpected total: %+v", billing)
+	}
+	expected := []*types.CategoryBilling{
+		{CategoryID: 2, Category: "coding", Count: 2, Duration: 2700, Hours: 0.75, Amount: 8000},
+		{CategoryID: 1, Category: "meeting", Count: 1, Duration: 3600, Hours: 1, Amount: 10000},
+	}
+	if !reflect.DeepEqual(billing.Categories, expected) {
+		t.Errorf("unexpected categories: expected=%+v, got=%+v", expected, billing.Categories)
+	}
+
+	// without rounding, 50, 20 and 10 minutes are billed as they are
+	billing = getBilling(t, server, "period=today")
+	if billing.Rounding != "none" || billing.Duration != 4800 || billing.Amount != 8333+4000+1333 {
+		t.Errorf("unexpected total without rounding: %+v", billing)
+	}
+
+	send(t, server, http.MethodPut, fmt.Sprintf("/activities/%d", unbilled.ID), &types.Activity{Billable: pointer.New(true)}, http.StatusOK, nil)
+	billing = getBilling(t, server, "period=today&rounding=up:15m")
+	if billing.Count != 4 || billing.Duration != 8100 || billing.Amount != 22000 {
+		t.Errorf("unexpected total after billing activity %d: %+v", unbilled.ID, billing)
+	}
+
+	res, err := http.Get(server.URL + "/reports/billing?rounding=up:15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioext.Close(res.Body)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected status of invalid rounding: expected=%d, got=%d", http.StatusBadRequest, res.StatusCode)
+	}
+}
+
+func getBilling(t *testing.T, server *httptest.Server, query string) *types.Billing {
+	t.Helper()
+
+	billing := new(types.Billing)
+	send(t, server, http.MethodGet, "/reports/billing?"+query, nil, http.StatusOK, billing)
+	return billing
+}
+
+func getSummary(t *testing.T, server *httptest.Server, query string) *types.Summary {
+	t.Helper()
+
+	res, err := http.Get(server.URL + "/reports/summary?" + query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: expected=%d, got=%d", http.StatusOK, res.StatusCode)
+	}
+	summary := new(types.Summary)
+	if err = json.NewDecoder(res.Body).Decode(summary); err != nil {
+		t.Fatal(err)
+	}
+	return summary
+}
+
+func assertCategorySummaries(t *testing.T, got, expected []*types.CategorySummary) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected categories: expected=%d, got=%d", len(expected), len(got))
+	}
+	for i := range expected {
+		if *got[i] != *expected[i] {
+			t.Errorf("unexpected category summary: \nexpected=%+v \ngot=%+v", expected[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"strconv"
+)
+
+type searchHandler struct {
+	searchService service.SearchService
+}
+
+func NewSearchHandler(searchService service.SearchService) Handler {
+	return &searchHandler{searchService: searchService}
+}
+
+func (h *searchHandler) Register(router *mux.Router) {
+	router.Path("/activities/_/search").HandlerFunc(h.SearchActivities).Methods(http.MethodGet)
+}
+
+// SearchActivities reads the words to search in the q query parameter, along
+// with the filter and the limit of the activities filter. Unlike the filter,
+// every period is searched unless one is set.
+func (h *searchHandler) SearchActivities(w http.ResponseWriter, r *http.Request) {
+	filter, err := periodFilterOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("period") == "" && query.Get("from") == "" && query.Get("to") == "" {
+		filter.PeriodName = ""
+	}
+	if query.Get("limit") != "" {
+		filter.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			problem.Write(w, r, invalidParam("limit", "must be an integer"))
+			return
+		}
+	}
+
+	results, err := h.searchService.SearchActivities(r.Context(), middlewares.UserID(r), &types.SearchFilter{
+		Query:        query.Get("q"),
+		PeriodFilter: *filter,
+	})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, results)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSearchHandler_SearchActivities(t *testing.T) {
+	var (
+		today  = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+		clock  = timeext.NewFrozenClock(today.Add(18 * time.Hour))
+		server = newTestServer(t, clock)
+	)
+
+	add := func(categoryID int64, description string, hour int) *types.Activity {
+		startedAt, finishedAt := today.Add(time.Duration(hour)*time.Hour), today.Add(time.Duration(hour+1)*time.Hour)
+		activity := new(types.Activity)
+		send(t, server, http.MethodPost, "/activities/_/manual", &types.Activity{
+			CategoryID:  categoryID,
+			Description: description,
+			StartedAt:   &startedAt,
+			FinishedAt:  &finishedAt,
+		}, http.StatusCreated, activity)
+		return activity
+	}
+
+	postmortem := add(2, "DB incident postmortem", 9)
+	triage := add(2, "incident triage #oncall", 10)
+	sync := add(1, "weekly sync", 11)
+	refactor := add(3, "refactor api", 12)
+
+	results := search(t, server, "q="+url.QueryEscape("Incident postmortem meeting"))
+	if len(results) != 3 || results[0].ID != postmortem.ID {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	found := make(map[int64]*types.SearchResult, len(results))
+	for _, result := range results {
+		found[result.ID] = result
+	}
+	for _, id := range []int64{triage.ID, sync.ID} {
+		if found[id] == nil {
+			t.Errorf("expected activity %d in results", id)
+		}
+	}
+	if found[refactor.ID] != nil {
+		t.Errorf("unexpected activity %d in results", refactor.ID)
+	}
+	if highlight := results[0].Highlight; highlight != "DB <mark>incident</mark> <mark>postmortem</mark>" {
+		t.Errorf("unexpected highlight: %s", highlight)
+	}
+	if results[0].Category != "coding" || found[sync.ID].Category != "meeting" {
+		t.Errorf("unexpected categories: %+v", results)
+	}
+
+	if results = search(t, server, "q=%23oncall"); len(results) != 1 || results[0].ID != triage.ID {
+		t.Errorf("unexpected results of tag: %+v", results)
+	}
+	if results = search(t, server, "q=incident&limit=1"); len(results) != 1 {
+		t.Errorf("unexpected results with limit: %+v", results)
+	}
+	if results = search(t, server, "q=incident&period=yesterday"); len(results) != 0 {
+		t.Errorf("unexpected results of yesterday: %+v", results)
+	}
+	if results = search(t, server, "q=deploy"); len(results) != 0 {
+		t.Errorf("unexpected results of deploy: %+v", results)
+	}
+
+	// % and _ in terms are not wildcards
+	fix := add(3, "fix user_id bug", 13)
+	add(3, "fix userxid", 14)
+	if results = search(t, server, "q=user_id"); len(results) != 1 || results[0].ID != fix.ID {
+		t.Errorf("unexpected results of user_id: %+v", results)
+	}
+
+	send(t, server, http.MethodGet, "/activities/_/search?q=+%23+", nil, http.StatusBadRequest, nil)
+	send(t, server, http.MethodGet, "/activities/_/search?q=incident&limit=1000", nil, http.StatusBadRequest, nil)
+}
+
+func search(t *testing.T, server *httptest.Server, query string) []*types.SearchResult {
+	t.Helper()
+	var results []*types.SearchResult
+	send(t, server, http.MethodGet, "/activities/_/search?"+query, nil, http.StatusOK, &results)
+	return results
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type tokensHandler struct {
+	tokensService service.TokensService
+}
+
+func NewTokensHandler(tokensService service.TokensService) Handler {
+	return &tokensHandler{tokensService: tokensService}
+}
+
+func (t *tokensHandler) Register(router *mux.Router) {
+	router.Path("/tokens").HandlerFunc(t.PostToken).Methods(http.MethodPost)
+	router.Path("/tokens").HandlerFunc(t.GetTokens).Methods(http.MethodGet)
+	router.Path("/tokens/{id}").HandlerFunc(t.RevokeToken).Methods(http.MethodDelete)
+}
+
+// PostToken creates a token of the user of the request, responding with its
+// secret, which can't be read again.
+func (t *tokensHandler) PostToken(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.Token)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	token, err := t.tokensService.CreateToken(r.Context(), middlewares.UserID(r), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, token.ID))
+	httpext.WriteJson(w, http.StatusCreated, token)
+}
+
+func (t *tokensHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := t.tokensService.GetTokens(r.Context(), middlewares.UserID(r))
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, tokens)
+}
+
+func (t *tokensHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	_, err = t.tokensService.RevokeToken(r.Context(), middlewares.UserID(r), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderEntity, fmt.Sprint(id))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type usersHandler struct {
+	usersService service.UsersService
+}
+
+func NewUsersHandler(usersService service.UsersService) Handler {
+	return &usersHandler{usersService: usersService}
+}
+
+func (u *usersHandler) Register(router *mux.Router) {
+	// registered before /users/{id}, which would match it otherwise
+	router.Path("/users/me").HandlerFunc(u.GetMe).Methods(http.MethodGet)
+	router.Path("/users/{id}").HandlerFunc(u.GetUser).Methods(http.MethodGet)
+	router.Path("/users").HandlerFunc(u.PostUser).Methods(http.MethodPost)
+	router.Path("/users").HandlerFunc(u.GetUsers).Methods(http.MethodGet)
+}
+
+func (u *usersHandler) PostUser(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	input := new(types.User)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		problem.Write(w, r, service.BadRequest(err))
+		return
+	}
+	user, err := u.usersService.CreateUser(r.Context(), input)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set(httpext.HeaderLocation, fmt.Sprintf("%s/%d", r.RequestURI, user.ID))
+	httpext.WriteJson(w, http.StatusCreated, user)
+}
+
+func (u *usersHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, invalidParam("id", "must be an integer"))
+		return
+	}
+	user, err := u.usersService.GetUser(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, user)
+}
+
+// GetMe responds with the user of the request.
+func (u *usersHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := u.usersService.GetUser(r.Context(), middlewares.UserID(r))
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, user)
+}
+
+func (u *usersHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := u.usersService.GetUsers(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	httpext.WriteJson(w, http.StatusOK, users)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUsersHandler_ActivitiesPerUser(t *testing.T) {
+	var (
+		clock  = timeext.NewFrozenClock(time.Date(2022, time.November, 1, 9, 0, 0, 0, time.UTC))
+		server = newTestServer(t, clock)
+		alice  = new(types.User)
+	)
+
+	send(t, server, http.MethodPost, "/users", &types.User{Name: " Alice "}, http.StatusCreated, alice)
+	if alice.Name != "alice" {
+		t.Errorf("unexpected name: expected=alice, got=%s", alice.Name)
+	}
+	send(t, server, http.MethodPost, "/users", &types.User{Name: "alice"}, http.StatusConflict, nil)
+	send(t, server, http.MethodPost, "/users", &types.User{Name: "alice smith"}, http.StatusUnprocessableEntity, nil)
+
+	me := new(types.User)
+	sendAs(t, server, "ALICE", http.MethodGet, "/users/me", nil, http.StatusOK, me)
+	if me.ID != alice.ID {
+		t.Errorf("unexpected user of the request: expected=%d, got=%d", alice.ID, me.ID)
+	}
+	sendAs(t, server, "bob", http.MethodGet, "/activities", nil, http.StatusUnauthorized, nil)
+
+	var (
+		mine   = new(types.Activity)
+		theirs = new(types.Activity)
+	)
+	sendAs(t, server, "alice", http.MethodPost, "/activities", &types.Activity{CategoryID: 1, Description: "mine"}, http.StatusCreated, mine)
+	clock.Add(time.Minute)
+	send(t, server, http.MethodPost, "/activities", &types.Activity{CategoryID: 1, Description: "theirs"}, http.StatusCreated, theirs)
+
+	// each user has its own running activity
+	for user, expected := range map[string]*types.Activity{"alice": mine, "": theirs} {
+		var activities []*types.Activity
+		sendAs(t, server, user, http.MethodGet, "/activities", nil, http.StatusOK, &activities)
+		if len(activities) != 1 || activities[0].ID != expected.ID || activities[0].Status != "STARTED" {
+			t.Errorf("unexpected activities of %q: %+v", user, activities)
+		}
+	}
+	if mine.UserID != alice.ID || theirs.UserID != types.DefaultUserID {
+		t.Errorf("unexpected users of the activities: %d and %d", mine.UserID, theirs.UserID)
+	}
+
+	send(t, server, http.MethodGet, fmt.Sprintf("/activities/%d/changes", mine.ID), nil, http.StatusNotFound, nil)
+	send(t, server, http.MethodDelete, fmt.Sprintf("/activities/%d", mine.ID), nil, http.StatusNotFound, nil)
+	sendAs(t, server, "alice", http.MethodPut, fmt.Sprintf("/activities/%d/finish", mine.ID), nil, http.StatusOK, nil)
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrMissingToken  = service.NewError(service.KindUnauthorized, "missing_token", "missing bearer token")
+	ErrReadOnlyToken = service.NewError(service.KindForbidden, "read_only_token", "token scope does not allow the request")
+)
+
+// Auth authenticates the requests by the bearer token of their Authorization
+// header, whose user makes the request. Read tokens are refused on requests
+// that change data. The exempt paths, such as /metrics, are not
+// authenticated, and the ones ending in a slash exempt the paths under them.
+func Auth(tokensService service.TokensService, usersService service.UsersService, exempt []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if isExempt(request.URL.Path, exempt) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			secret, ok := bearerToken(request)
+			if !ok {
+				unauthorized(writer, request, ErrMissingToken)
+				return
+			}
+
+			token, err := tokensService.Authenticate(request.Context(), secret)
+			if errors.Is(err, service.ErrUnknownToken) {
+				unauthorized(writer, request, err)
+				return
+			}
+			if err != nil {
+				problem.Write(writer, request, err)
+				return
+			}
+			if !token.Allows(request.Method) {
+				problem.Write(writer, request, ErrReadOnlyToken)
+				return
+			}
+
+			user, err := usersService.GetUser(request.Context(), token.UserID)
+			if err != nil {
+				problem.Write(writer, request, err)
+				return
+			}
+
+			ctx := context.WithValue(request.Context(), userKey{}, user)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+func isExempt(path string, exempt []string) bool {
+	for _, p := range exempt {
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerToken(request *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(request.Header.Get(httpext.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(writer http.ResponseWriter, request *http.Request, err error) {
+	writer.Header().Set(httpext.HeaderWWWAuthenticate, `Bearer realm="timetrack"`)
+	problem.Write(writer, request, err)
+}
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"context"
+	"github.com/ungame/timetrack/app/problem"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"net/http"
+	"strings"
+)
+
+type userKey struct{}
+
+var ErrUserMismatch = service.NewError(service.KindForbidden, "user_mismatch", "user is not the one of the token")
+
+// Users identifies the user of each request by the name in the X-User
+// header. Requests without it are made by the default user, and the ones
+// naming an unknown user are refused. Requests already authenticated by Auth
+// are made by the user of their token, which the header may only repeat.
+func Users(usersService service.UsersService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			name := request.Header.Get(httpext.HeaderUser)
+			if user, ok := request.Context().Value(userKey{}).(*types.User); ok {
+				if name != "" && !strings.EqualFold(strings.TrimSpace(name), user.Name) {
+					problem.Write(writer, request, ErrUserMismatch)
+					return
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+			if name == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			user, err := usersService.GetUserByName(request.Context(), name)
+			if err != nil {
+				problem.Write(writer, request, err)
+				return
+			}
+
+			ctx := context.WithValue(request.Context(), userKey{}, user)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// UserID returns the id of the user identified by Users, or the default user
+// when the request did not name one.
+func UserID(request *http.Request) int64 {
+	if user, ok := request.Context().Value(userKey{}).(*types.User); ok {
+		return user.ID
+	}
+	return types.DefaultUserID
+}
@@ -10,8 +10,11 @@ import (
 type ActivityStatus string
 
 func (a ActivityStatus) String() string {
-	if a == Started {
+	switch a {
+	case Started:
 		return "STARTED"
+	case Paused:
+		return "PAUSED"
 	}
 	return "FINISHED"
 }
@@ -19,50 +22,166 @@ func (a ActivityStatus) String() string {
 const (
 	Started  ActivityStatus = "1"
 	Finished ActivityStatus = "0"
+	Paused   ActivityStatus = "2"
 )
 
 type Activity struct {
 	ID          int64
+	UserID      int64
 	CategoryID  int64
+	ProjectID   sql.NullInt64
+	Billable    bool
+	HourlyRate  sql.NullInt64
 	Description string
 	Status      ActivityStatus
 	StartedAt   sql.NullTime
 	UpdatedAt   sql.NullTime
 	FinishedAt  sql.NullTime
+	Tags        []string
+	Intervals   []*ActivityInterval
 }
 
+// SetStartedAt and the other setters keep times in UTC, so that they are
+// stored with the same offset and compare correctly in every database.
 func (a *Activity) SetStartedAt(startedAt time.Time) {
 	a.StartedAt = sql.NullTime{
-		Time:  startedAt,
+		Time:  startedAt.UTC(),
 		Valid: !startedAt.IsZero(),
 	}
 }
 
 func (a *Activity) SetUpdatedAt(updatedAt time.Time) {
 	a.UpdatedAt = sql.NullTime{
-		Time:  updatedAt,
+		Time:  updatedAt.UTC(),
 		Valid: !updatedAt.IsZero(),
 	}
 }
 
 func (a *Activity) SetFinishedAt(finishedAt time.Time) {
 	a.FinishedAt = sql.NullTime{
-		Time:  finishedAt,
+		Time:  finishedAt.UTC(),
 		Valid: !finishedAt.IsZero(),
 	}
 }
 
-func (a *Activity) Type() *types.Activity {
+// Duration returns the net worked time of the activity, i.e. the sum of its
+// work intervals, counting open intervals until now. Activities recorded
+// before work intervals existed fall back to the time between start and
+// finish.
+func (a *Activity) Duration(now time.Time) time.Duration {
+	if len(a.Intervals) == 0 {
+		end := now
+		if a.FinishedAt.Valid {
+			end = a.FinishedAt.Time
+		}
+		return end.Sub(a.StartedAt.Time)
+	}
+	var total time.Duration
+	for _, interval := range a.Intervals {
+		total += interval.Duration(now)
+	}
+	return total
+}
+
+// Type returns the activity of the API, whose duration counts the open work
+// interval until now.
+func (a *Activity) Type(now time.Time) *types.Activity {
 	activity := &types.Activity{
 		ID:          a.ID,
+		UserID:      a.UserID,
 		CategoryID:  a.CategoryID,
+		Billable:    pointer.New(a.Billable),
 		Description: a.Description,
 		Status:      a.Status.String(),
 		StartedAt:   pointer.New(a.StartedAt.Time),
 		UpdatedAt:   pointer.New(a.UpdatedAt.Time),
+		Duration:    int64(a.Duration(now).Seconds()),
+		Tags:        a.Tags,
 	}
 	if a.FinishedAt.Valid {
 		activity.FinishedAt = pointer.New(a.FinishedAt.Time)
 	}
+	if a.ProjectID.Valid {
+		activity.ProjectID = pointer.New(a.ProjectID.Int64)
+	}
+	if a.HourlyRate.Valid {
+		activity.HourlyRate = pointer.New(a.HourlyRate.Int64)
+	}
+	for _, interval := range a.Intervals {
+		activity.Intervals = append(activity.Intervals, interval.Type())
+	}
 	return activity
 }
+
+type ActivityInterval struct {
+	ID         int64
+	ActivityID int64
+	StartedAt  sql.NullTime
+	FinishedAt sql.NullTime
+}
+
+func (i *ActivityInterval) SetStartedAt(startedAt time.Time) {
+	i.StartedAt = sql.NullTime{
+		Time:  startedAt.UTC(),
+		Valid: !startedAt.IsZero(),
+	}
+}
+
+func (i *ActivityInterval) SetFinishedAt(finishedAt time.Time) {
+	i.FinishedAt = sql.NullTime{
+		Time:  finishedAt.UTC(),
+		Valid: !finishedAt.IsZero(),
+	}
+}
+
+func (i *ActivityInterval) Duration(now time.Time) time.Duration {
+	if i.FinishedAt.Valid {
+		return i.FinishedAt.Time.Sub(i.StartedAt.Time)
+	}
+	return now.Sub(i.StartedAt.Time)
+}
+
+func (i *ActivityInterval) Type() *types.ActivityInterval {
+	interval := &types.ActivityInterval{
+		ID:        i.ID,
+		StartedAt: pointer.New(i.StartedAt.Time),
+	}
+	if i.FinishedAt.Valid {
+		interval.FinishedAt = pointer.New(i.FinishedAt.Time)
+	}
+	return interval
+}
+
+type ActivityChange struct {
+	ID         int64
+	ActivityID int64
+	Field      string
+	OldValue   sql.NullString
+	NewValue   sql.NullString
+	ChangedAt  sql.NullTime
+}
+
+func NewActivityChange(field string, oldValue, newValue sql.NullString, changedAt time.Time) *ActivityChange {
+	return &ActivityChange{
+		Field:     field,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		ChangedAt: sql.NullTime{Time: changedAt.UTC(), Valid: !changedAt.IsZero()},
+	}
+}
+
+func (c *ActivityChange) Type() *types.ActivityChange {
+	change := &types.ActivityChange{
+		ID:         c.ID,
+		ActivityID: c.ActivityID,
+		Field:      c.Field,
+		ChangedAt:  pointer.New(c.ChangedAt.Time),
+	}
+	if c.OldValue.Valid {
+		change.OldValue = pointer.New(c.OldValue.String)
+	}
+	if c.NewValue.Valid {
+		change.NewValue = pointer.New(c.NewValue.String)
+	}
+	return change
+}
@@ -13,18 +13,19 @@ type Category struct {
 	Description string
 	CreatedAt   sql.NullTime
 	UpdatedAt   sql.NullTime
+	HourlyRate  int64
 }
 
 func (c *Category) SetCreatedAt(createdAt time.Time) {
 	c.CreatedAt = sql.NullTime{
-		Time:  createdAt,
+		Time:  createdAt.UTC(),
 		Valid: !createdAt.IsZero(),
 	}
 }
 
 func (c *Category) SetUpdatedAt(updatedAt time.Time) {
 	c.UpdatedAt = sql.NullTime{
-		Time:  updatedAt,
+		Time:  updatedAt.UTC(),
 		Valid: !updatedAt.IsZero(),
 	}
 }
@@ -34,6 +35,7 @@ func (c *Category) Type() *types.Category {
 		ID:          c.ID,
 		Name:        c.Name,
 		Description: c.Description,
+		HourlyRate:  c.HourlyRate,
 		CreatedAt:   pointer.New(c.CreatedAt.Time),
 		UpdatedAt:   pointer.New(c.UpdatedAt.Time),
 	}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/types"
+	"time"
+)
+
+type Client struct {
+	ID          int64
+	Name        string
+	Description string
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
+
+func (c *Client) SetCreatedAt(createdAt time.Time) {
+	c.CreatedAt = sql.NullTime{
+		Time:  createdAt.UTC(),
+		Valid: !createdAt.IsZero(),
+	}
+}
+
+func (c *Client) SetUpdatedAt(updatedAt time.Time) {
+	c.UpdatedAt = sql.NullTime{
+		Time:  updatedAt.UTC(),
+		Valid: !updatedAt.IsZero(),
+	}
+}
+
+func (c *Client) Type() *types.Client {
+	return &types.Client{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		CreatedAt:   pointer.New(c.CreatedAt.Time),
+		UpdatedAt:   pointer.New(c.UpdatedAt.Time),
+	}
+}
+
+// Project is a piece of work, optionally done for a client.
+type Project struct {
+	ID          int64
+	ClientID    sql.NullInt64
+	Name        string
+	Description string
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
+
+func (p *Project) SetCreatedAt(createdAt time.Time) {
+	p.CreatedAt = sql.NullTime{
+		Time:  createdAt.UTC(),
+		Valid: !createdAt.IsZero(),
+	}
+}
+
+func (p *Project) SetUpdatedAt(updatedAt time.Time) {
+	p.UpdatedAt = sql.NullTime{
+		Time:  updatedAt.UTC(),
+		Valid: !updatedAt.IsZero(),
+	}
+}
+
+func (p *Project) Type() *types.Project {
+	return &types.Project{
+		ID:          p.ID,
+		ClientID:    p.ClientID.Int64,
+		Name:        p.Name,
+		Description: p.Description,
+		CreatedAt:   pointer.New(p.CreatedAt.Time),
+		UpdatedAt:   pointer.New(p.UpdatedAt.Time),
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+// CategoryDaySummary is the time tracked in a category on a day.
+type CategoryDaySummary struct {
+	Date       string
+	CategoryID int64
+	Category   string
+	Count      int64
+	Seconds    float64
+}
+
+// TagSummary is the time tracked in activities with a tag.
+type TagSummary struct {
+	Tag     string
+	Count   int64
+	Seconds float64
+}
+
+// BillableEntry is the time worked in a billable activity and the hourly rate
+// it is billed at.
+type BillableEntry struct {
+	ActivityID int64
+	CategoryID int64
+	Category   string
+	HourlyRate int64
+	Seconds    float64
+}
+
+// SearchHit is an activity found by a search, with the name of its category
+// and a score that is higher for better matches.
+type SearchHit struct {
+	Activity *Activity
+	Category string
+	Score    float64
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"database/sql"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/types"
+	"time"
+)
+
+// Token is an API token of a user, stored as the hash of its secret.
+type Token struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Hash      string
+	Scope     string
+	CreatedAt sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+func (t *Token) SetCreatedAt(createdAt time.Time) {
+	t.CreatedAt = sql.NullTime{
+		Time:  createdAt.UTC(),
+		Valid: !createdAt.IsZero(),
+	}
+}
+
+func (t *Token) SetRevokedAt(revokedAt time.Time) {
+	t.RevokedAt = sql.NullTime{
+		Time:  revokedAt.UTC(),
+		Valid: !revokedAt.IsZero(),
+	}
+}
+
+func (t *Token) Revoked() bool {
+	return t.RevokedAt.Valid
+}
+
+func (t *Token) Type() *types.Token {
+	token := &types.Token{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Scope:     t.Scope,
+		CreatedAt: pointer.New(t.CreatedAt.Time),
+	}
+	if t.RevokedAt.Valid {
+		token.RevokedAt = pointer.New(t.RevokedAt.Time)
+	}
+	return token
+}
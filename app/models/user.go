@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/types"
+	"time"
+)
+
+// User is a member of the team, who owns the activities it tracks.
+type User struct {
+	ID        int64
+	Name      string
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+func (u *User) SetCreatedAt(createdAt time.Time) {
+	u.CreatedAt = sql.NullTime{
+		Time:  createdAt.UTC(),
+		Valid: !createdAt.IsZero(),
+	}
+}
+
+func (u *User) SetUpdatedAt(updatedAt time.Time) {
+	u.UpdatedAt = sql.NullTime{
+		Time:  updatedAt.UTC(),
+		Valid: !updatedAt.IsZero(),
+	}
+}
+
+func (u *User) Type() *types.User {
+	return &types.User{
+		ID:        u.ID,
+		Name:      u.Name,
+		CreatedAt: pointer.New(u.CreatedAt.Time),
+		UpdatedAt: pointer.New(u.UpdatedAt.Time),
+	}
+}
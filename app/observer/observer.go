@@ -10,7 +10,7 @@ const DefaultNamespace = "timetrack"
 
 type Observer interface {
 	Count(ctx string, category string)
-	DurationOf(ctx, category string, startedAt time.Time)
+	DurationOf(ctx, category string, duration time.Duration)
 }
 
 type observerImpl struct {
@@ -55,10 +55,10 @@ func (o *observerImpl) Count(ctx, category string) {
 	}()
 }
 
-func (o *observerImpl) DurationOf(ctx, category string, startedAt time.Time) {
+func (o *observerImpl) DurationOf(ctx, category string, duration time.Duration) {
 	go func() {
 		o.duration.
 			WithLabelValues(ctx, fmt.Sprint(category)).
-			Observe(float64(time.Since(startedAt).Nanoseconds()) / 1e6)
+			Observe(float64(duration.Nanoseconds()) / 1e6)
 	}()
 }
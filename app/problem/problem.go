@@ -0,0 +1,70 @@
+package problem
+
+import (
+	"errors"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"net/http"
+)
+
+// TypePrefix starts the type of the problems, followed by their code.
+const TypePrefix = "urn:timetrack:problem:"
+
+var errInternal = service.NewError(service.KindInternal, "internal", "internal error")
+
+// Status returns the HTTP status of the errors of the kind.
+func Status(kind service.Kind) int {
+	switch kind {
+	case service.KindBadRequest:
+		return http.StatusBadRequest
+	case service.KindValidation:
+		return http.StatusUnprocessableEntity
+	case service.KindNotFound:
+		return http.StatusNotFound
+	case service.KindConflict:
+		return http.StatusConflict
+	case service.KindUnauthorized:
+		return http.StatusUnauthorized
+	case service.KindForbidden:
+		return http.StatusForbidden
+	case service.KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Of returns the problem of the error of the request. Errors that aren't
+// domain errors, such as the ones of the database, are internal errors,
+// whose details are logged instead of sent to the client.
+func Of(r *http.Request, err error) *types.Problem {
+	domainErr := errInternal
+	errors.As(err, &domainErr)
+
+	problem := &types.Problem{
+		Type:     TypePrefix + domainErr.Code,
+		Title:    domainErr.Message,
+		Status:   Status(domainErr.Kind),
+		Instance: r.URL.Path,
+		Code:     domainErr.Code,
+	}
+
+	if domainErr.Kind == service.KindInternal {
+		log.Printf("%s %s failed with error: %v\n", r.Method, r.URL.Path, err)
+		return problem
+	}
+
+	problem.Detail = err.Error()
+	var fieldErr *service.FieldError
+	if errors.As(err, &fieldErr) {
+		problem.Fields = append(problem.Fields, &types.ProblemField{Field: fieldErr.Field, Message: fieldErr.Message})
+	}
+	return problem
+}
+
+// Write writes the problem of the error of the request.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	httpext.WriteProblem(w, Of(r, err))
+}
@@ -5,192 +5,518 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/ioext"
 	"github.com/ungame/timetrack/queries"
 	"time"
 )
 
 const (
-	createActivityQuery       = "insert into activities (category_id, description, status, started_at, updated_at) values (?, ?, ?, ?, ?)"
-	updateActivityQuery       = "update activities set category_id = ?, description = ?, status = ?, updated_at = ?, finished_at = ? where id = ?"
-	deleteActivityQuery       = "delete from activities where id = ?"
+	activityColumns           = "id, user_id, category_id, project_id, billable, hourly_rate, description, status, started_at, updated_at, finished_at"
+	createActivityQuery       = "insert into activities (user_id, category_id, project_id, billable, hourly_rate, description, status, started_at, updated_at, finished_at, running) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	updateActivityQuery       = "update activities set category_id = ?, project_id = ?, billable = ?, hourly_rate = ?, description = ?, status = ?, started_at = ?, updated_at = ?, finished_at = ?, running = ? where id = ? and user_id = ?"
+	finishActivityQuery       = "update activities set status = ?, updated_at = ?, finished_at = ?, running = null where id = ? and status = ?"
+	createChangeQuery         = "insert into activity_changes (activity_id, field, old_value, new_value, changed_at) values (?, ?, ?, ?, ?)"
+	countUserActivityQuery    = "select count(*) from activities where id = ? and user_id = ?"
+	deleteActivityQuery       = "delete from activities where id = ? and user_id = ?"
+	deleteChangesQuery        = "delete from activity_changes where activity_id = ?"
 	defaultPrepareStmtTimeout = time.Second * 30
 )
 
+// ActivitiesRepository stores the activities of the users. Activities are
+// saved for their UserID, and every other method only sees the activities of
+// the given user.
 type ActivitiesRepository interface {
 	Create(ctx context.Context, activity *models.Activity) (*models.Activity, error)
-	Get(ctx context.Context, id int64) (*models.Activity, error)
-	GetAll(ctx context.Context) ([]*models.Activity, error)
-	GetByStatus(ctx context.Context, status models.ActivityStatus) ([]*models.Activity, error)
-	FilterByPeriod(ctx context.Context, period queries.Period, order queries.Order, limit int) ([]*models.Activity, error)
-	Update(ctx context.Context, activity *models.Activity) (*models.Activity, error)
-	Delete(ctx context.Context, id int64) (int64, error)
+	Add(ctx context.Context, activity *models.Activity) ([]*models.Activity, error)
+	Start(ctx context.Context, activity *models.Activity) (*models.Activity, []*models.Activity, error)
+	Get(ctx context.Context, userID, id int64) (*models.Activity, error)
+	GetAll(ctx context.Context, userID int64, filter *ActivityFilter, afterID int64, limit int) ([]*models.Activity, error)
+	GetByStatus(ctx context.Context, userID int64, status models.ActivityStatus) ([]*models.Activity, error)
+	FilterByPeriod(ctx context.Context, userID int64, start, end time.Time, filter *ActivityFilter, order queries.Order, limit int) ([]*models.Activity, error)
+	GetOverlapping(ctx context.Context, userID int64, start, end time.Time, excludeID int64) ([]*models.Activity, error)
+	Update(ctx context.Context, activity *models.Activity, changes ...*models.ActivityChange) (*models.Activity, error)
+	Correct(ctx context.Context, activity *models.Activity, end time.Time, changes ...*models.ActivityChange) ([]*models.Activity, error)
+	UpdateStatus(ctx context.Context, activity *models.Activity, at time.Time) (*models.Activity, []*models.Activity, error)
+	GetChanges(ctx context.Context, userID, id int64) ([]*models.ActivityChange, error)
+	Delete(ctx context.Context, userID, id int64) (int64, error)
 	Truncate(ctx context.Context) error
 	Close()
 }
 
 type activitiesRepository struct {
 	conn       *sql.DB
+	dialect    db.Dialect
 	createStmt *sql.Stmt
 	updateStmt *sql.Stmt
 	deleteStmt *sql.Stmt
 }
 
-func NewActivitiesRepository(conn *sql.DB) ActivitiesRepository {
+func NewActivitiesRepository(conn *sql.DB, dialect db.Dialect) ActivitiesRepository {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultPrepareStmtTimeout)
 	defer cancel()
 
 	return &activitiesRepository{
 		conn:       conn,
-		createStmt: queries.MustPrepare(ctx, conn, createActivityQuery),
+		dialect:    dialect,
+		createStmt: queries.MustPrepare(ctx, conn, dialect.ReturningID(createActivityQuery)),
 		updateStmt: queries.MustPrepare(ctx, conn, updateActivityQuery),
 		deleteStmt: queries.MustPrepare(ctx, conn, deleteActivityQuery),
 	}
 }
 
+// Create saves the activity along with its first work interval: an open one
+// for started activities, or a closed one for activities created finished.
+// Creating a started activity fails while another one of the user is
+// running, use Start to replace the running activity instead.
 func (r *activitiesRepository) Create(ctx context.Context, activity *models.Activity) (*models.Activity, error) {
-	result, err := r.createStmt.ExecContext(
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = r.create(ctx, tx, activity); err != nil {
+		return nil, err
+	}
+
+	return activity, tx.Commit()
+}
+
+// Add saves a finished activity unless it overlaps finished activities of its
+// user, which are returned instead. The overlap is checked in the same
+// serializable transaction as the insert, so that concurrent calls can't save
+// overlapping activities.
+func (r *activitiesRepository) Add(ctx context.Context, activity *models.Activity) ([]*models.Activity, error) {
+	var overlapping []*models.Activity
+
+	err := retryOnConflict(ctx, r.conn, serializable, func(tx *sql.Tx) (err error) {
+		overlapping, err = getOverlapping(ctx, tx, activity.UserID, activity.StartedAt.Time, activity.FinishedAt.Time, 0)
+		if err != nil || len(overlapping) > 0 {
+			return err
+		}
+		return r.create(ctx, tx, activity)
+	})
+
+	return overlapping, err
+}
+
+// Start saves a started activity and finishes, in the same transaction, the
+// activity of the user that was running, exactly at the start of the new one.
+// Together with the unique running flag of the activities table this
+// guarantees that at most one activity per user is running, even under
+// concurrent calls.
+func (r *activitiesRepository) Start(ctx context.Context, activity *models.Activity) (*models.Activity, []*models.Activity, error) {
+	var (
+		finished  []*models.Activity
+		startedAt = activity.StartedAt.Time
+	)
+
+	err := retryOnConflict(ctx, r.conn, nil, func(tx *sql.Tx) error {
+		var (
+			at  time.Time
+			err error
+		)
+		finished, at, err = r.finishRunning(ctx, tx, activity.UserID, 0, startedAt)
+		if err != nil {
+			return err
+		}
+		activity.SetStartedAt(at)
+		activity.SetUpdatedAt(at)
+		return r.create(ctx, tx, activity)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return activity, finished, nil
+}
+
+func (r *activitiesRepository) create(ctx context.Context, tx *sql.Tx, activity *models.Activity) error {
+	var err error
+	activity.ID, err = insertStmt(
 		ctx,
+		tx.StmtContext(ctx, r.createStmt),
+		r.dialect,
+		activity.UserID,
 		activity.CategoryID,
+		activity.ProjectID,
+		activity.Billable,
+		activity.HourlyRate,
 		activity.Description,
 		activity.Status,
 		activity.StartedAt,
 		activity.UpdatedAt,
+		activity.FinishedAt,
+		running(activity),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	activity.ID, err = result.LastInsertId()
-	return activity, err
+
+	activity.Intervals = nil
+	if activity.Status != models.Paused {
+		interval := &models.ActivityInterval{
+			ActivityID: activity.ID,
+			StartedAt:  activity.StartedAt,
+			FinishedAt: activity.FinishedAt,
+		}
+		if err = createInterval(ctx, tx, r.dialect, interval); err != nil {
+			return err
+		}
+		activity.Intervals = []*models.ActivityInterval{interval}
+	}
+
+	return saveTags(ctx, tx, r.dialect, activity)
+}
+
+// finishRunning finishes the running activities of the user, other than
+// excludeID, at the given time, which is returned. If a running activity
+// started or resumed later, which happens when a concurrent request won the
+// race, that time is used instead so that no work interval finishes before it
+// started.
+func (r *activitiesRepository) finishRunning(ctx context.Context, tx *sql.Tx, userID, excludeID int64, at time.Time) ([]*models.Activity, time.Time, error) {
+	query := "select " + activityColumns + " from activities where user_id = ? and status = ? and id <> ?"
+	rows, err := tx.QueryContext(ctx, query, userID, models.Started, excludeID)
+	if err != nil {
+		return nil, at, err
+	}
+	items, err := scanActivities(rows, 1)
+	if err != nil {
+		return nil, at, err
+	}
+	if err = loadIntervals(ctx, tx, items...); err != nil {
+		return nil, at, err
+	}
+
+	for _, item := range items {
+		if item.StartedAt.Time.After(at) {
+			at = item.StartedAt.Time
+		}
+		for _, interval := range item.Intervals {
+			if interval.StartedAt.Time.After(at) {
+				at = interval.StartedAt.Time
+			}
+		}
+	}
+
+	finished := make([]*models.Activity, 0, len(items))
+	for _, item := range items {
+		result, err := tx.ExecContext(ctx, finishActivityQuery, models.Finished, at.UTC(), at.UTC(), item.ID, models.Started)
+		if err != nil {
+			return nil, at, err
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			continue
+		}
+		item.Status = models.Finished
+		item.SetUpdatedAt(at)
+		item.SetFinishedAt(at)
+		if err = closeInterval(ctx, tx, r.dialect, item, at); err != nil {
+			return nil, at, err
+		}
+		finished = append(finished, item)
+	}
+
+	return finished, at, nil
 }
 
-func (r *activitiesRepository) Get(ctx context.Context, id int64) (*models.Activity, error) {
+func (r *activitiesRepository) Get(ctx context.Context, userID, id int64) (*models.Activity, error) {
 	var (
-		query    = "select * from activities where id = ?"
-		row      = r.conn.QueryRowContext(ctx, query, id)
+		query    = "select " + activityColumns + " from activities where id = ? and user_id = ?"
+		row      = r.conn.QueryRowContext(ctx, query, id, userID)
 		activity = new(models.Activity)
 	)
-	err := row.Scan(
-		&activity.ID,
-		&activity.CategoryID,
-		&activity.Description,
-		&activity.Status,
-		&activity.StartedAt,
-		&activity.UpdatedAt,
-		&activity.FinishedAt,
-	)
-	return activity, err
+	if err := row.Scan(activityFields(activity)...); err != nil {
+		return activity, err
+	}
+	return activity, loadDetails(ctx, r.conn, activity)
 }
 
-func (r *activitiesRepository) GetAll(ctx context.Context) ([]*models.Activity, error) {
-	query := "select * from activities"
-	rows, err := r.conn.QueryContext(ctx, query)
+// GetAll returns up to limit activities with id greater than afterID ordered
+// by id, only the ones of the filter if it is set, so that pages are read
+// with the last id of the previous one.
+func (r *activitiesRepository) GetAll(ctx context.Context, userID int64, filter *ActivityFilter, afterID int64, limit int) ([]*models.Activity, error) {
+	var (
+		query = "select " + activityColumns + " from activities where user_id = ? and id > ?"
+		args  = []any{userID, afterID}
+	)
+	if conditions, filterArgs := filter.where(""); conditions != "" {
+		query += " and " + conditions
+		args = append(args, filterArgs...)
+	}
+	query += " order by id limit ?"
+	args = append(args, limit)
+
+	rows, err := r.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer ioext.Close(rows)
-	activities := make([]*models.Activity, 0, 10)
-	for rows.Next() {
-		activity := new(models.Activity)
-		err = rows.Scan(
-			&activity.ID,
-			&activity.CategoryID,
-			&activity.Description,
-			&activity.Status,
-			&activity.StartedAt,
-			&activity.UpdatedAt,
-			&activity.FinishedAt,
+	activities, err := scanActivities(rows, limit)
+	if err != nil {
+		return activities, err
+	}
+	return activities, loadDetails(ctx, r.conn, activities...)
+}
+
+// Update saves the activity and its work intervals, and records the given
+// changes in the same transaction.
+func (r *activitiesRepository) Update(ctx context.Context, activity *models.Activity, changes ...*models.ActivityChange) (*models.Activity, error) {
+	err := inTx(ctx, r.conn, nil, func(tx *sql.Tx) error {
+		return r.save(ctx, tx, activity, changes)
+	})
+	return activity, err
+}
+
+// Correct saves the activity, whose times were corrected, as Update does
+// unless it overlaps, from its start until end, finished activities of its
+// user, which are returned instead. The overlap is checked in the same
+// serializable transaction as the update, as in Add.
+func (r *activitiesRepository) Correct(ctx context.Context, activity *models.Activity, end time.Time, changes ...*models.ActivityChange) ([]*models.Activity, error) {
+	var overlapping []*models.Activity
+
+	err := retryOnConflict(ctx, r.conn, serializable, func(tx *sql.Tx) (err error) {
+		overlapping, err = getOverlapping(ctx, tx, activity.UserID, activity.StartedAt.Time, end, activity.ID)
+		if err != nil || len(overlapping) > 0 {
+			return err
+		}
+		return r.save(ctx, tx, activity, changes)
+	})
+
+	return overlapping, err
+}
+
+// save updates the activity with its tags and work intervals and records the
+// changes in its history.
+func (r *activitiesRepository) save(ctx context.Context, tx *sql.Tx, activity *models.Activity, changes []*models.ActivityChange) (err error) {
+	if err = r.update(ctx, tx, activity); err != nil {
+		return err
+	}
+
+	if err = saveTags(ctx, tx, r.dialect, activity); err != nil {
+		return err
+	}
+
+	for _, interval := range activity.Intervals {
+		if err = updateInterval(ctx, tx, interval); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range changes {
+		change.ID, err = insert(
+			ctx,
+			tx,
+			r.dialect,
+			createChangeQuery,
+			activity.ID,
+			change.Field,
+			change.OldValue,
+			change.NewValue,
+			change.ChangedAt,
 		)
 		if err != nil {
-			return activities, err
+			return err
 		}
-		activities = append(activities, activity)
+		change.ActivityID = activity.ID
 	}
-	return activities, err
+
+	return nil
 }
 
-func (r *activitiesRepository) Update(ctx context.Context, activity *models.Activity) (*models.Activity, error) {
-	_, err := r.updateStmt.ExecContext(
+// UpdateStatus saves the activity after a status transition at the given
+// time: a work interval is opened when the activity becomes started and the
+// open one is closed otherwise. An activity that becomes started finishes the
+// running one of its user, as Start does, and the finished activities are
+// returned.
+func (r *activitiesRepository) UpdateStatus(ctx context.Context, activity *models.Activity, at time.Time) (*models.Activity, []*models.Activity, error) {
+	var (
+		finished  []*models.Activity
+		intervals = activity.Intervals
+	)
+
+	err := retryOnConflict(ctx, r.conn, nil, func(tx *sql.Tx) (err error) {
+		activity.Intervals = intervals
+		if activity.Status == models.Started {
+			if finished, at, err = r.finishRunning(ctx, tx, activity.UserID, activity.ID, at); err != nil {
+				return err
+			}
+			activity.SetUpdatedAt(at)
+		}
+
+		if err = r.update(ctx, tx, activity); err != nil {
+			return err
+		}
+
+		if activity.Status != models.Started {
+			return closeInterval(ctx, tx, r.dialect, activity, at)
+		}
+
+		interval := &models.ActivityInterval{ActivityID: activity.ID}
+		interval.SetStartedAt(at)
+		if err = createInterval(ctx, tx, r.dialect, interval); err != nil {
+			return err
+		}
+		activity.Intervals = append(activity.Intervals, interval)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return activity, finished, nil
+}
+
+func (r *activitiesRepository) update(ctx context.Context, tx *sql.Tx, activity *models.Activity) error {
+	_, err := tx.StmtContext(ctx, r.updateStmt).ExecContext(
 		ctx,
 		activity.CategoryID,
+		activity.ProjectID,
+		activity.Billable,
+		activity.HourlyRate,
 		activity.Description,
 		activity.Status,
+		activity.StartedAt,
 		activity.UpdatedAt,
 		activity.FinishedAt,
+		running(activity),
 		activity.ID,
+		activity.UserID,
 	)
-	return activity, err
-}
-
-func (r *activitiesRepository) Delete(ctx context.Context, id int64) (int64, error) {
-	result, err := r.deleteStmt.ExecContext(ctx, id)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected()
+	return err
 }
 
-func (r *activitiesRepository) GetByStatus(ctx context.Context, status models.ActivityStatus) ([]*models.Activity, error) {
-	query := "select * from activities where status = ?"
-	rows, err := r.conn.QueryContext(ctx, query, status)
+func (r *activitiesRepository) GetChanges(ctx context.Context, userID, id int64) ([]*models.ActivityChange, error) {
+	query := `select c.id, c.activity_id, c.field, c.old_value, c.new_value, c.changed_at
+		from activity_changes c
+		join activities a on a.id = c.activity_id
+		where c.activity_id = ? and a.user_id = ?
+		order by c.id`
+	rows, err := r.conn.QueryContext(ctx, query, id, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer ioext.Close(rows)
-	activities := make([]*models.Activity, 0, 10)
+	changes := make([]*models.ActivityChange, 0, 10)
 	for rows.Next() {
-		activity := new(models.Activity)
+		change := new(models.ActivityChange)
 		err = rows.Scan(
-			&activity.ID,
-			&activity.CategoryID,
-			&activity.Description,
-			&activity.Status,
-			&activity.StartedAt,
-			&activity.UpdatedAt,
-			&activity.FinishedAt,
+			&change.ID,
+			&change.ActivityID,
+			&change.Field,
+			&change.OldValue,
+			&change.NewValue,
+			&change.ChangedAt,
 		)
 		if err != nil {
-			return activities, err
+			return changes, err
 		}
-		activities = append(activities, activity)
+		changes = append(changes, change)
+	}
+	return changes, err
+}
+
+// Delete removes the activity of the user along with its changes, work
+// intervals and tags, and returns the number of removed activities.
+func (r *activitiesRepository) Delete(ctx context.Context, userID, id int64) (int64, error) {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int64
+	if err = tx.QueryRowContext(ctx, countUserActivityQuery, id, userID).Scan(&count); err != nil || count == 0 {
+		return 0, err
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteChangesQuery, id); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, deleteIntervalsQuery, id); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, deleteActivityTagsQuery, id); err != nil {
+		return 0, err
 	}
-	return activities, err
+	result, err := tx.StmtContext(ctx, r.deleteStmt).ExecContext(ctx, id, userID)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return rows, tx.Commit()
 }
 
-func (r *activitiesRepository) FilterByPeriod(ctx context.Context, period queries.Period, order queries.Order, limit int) ([]*models.Activity, error) {
+func (r *activitiesRepository) GetByStatus(ctx context.Context, userID int64, status models.ActivityStatus) ([]*models.Activity, error) {
+	query := "select " + activityColumns + " from activities where user_id = ? and status = ?"
+	rows, err := r.conn.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	activities, err := scanActivities(rows, 10)
+	if err != nil {
+		return activities, err
+	}
+	return activities, loadDetails(ctx, r.conn, activities...)
+}
+
+// FilterByPeriod returns up to limit activities of the user started within
+// [start, end], or all of them if limit is 0, only the ones of the filter if
+// it is set.
+func (r *activitiesRepository) FilterByPeriod(ctx context.Context, userID int64, start, end time.Time, filter *ActivityFilter, order queries.Order, limit int) ([]*models.Activity, error) {
 	var (
-		query      = "select * from activities where (started_at >= ? and started_at <= ?) order by id " + order.String() + " limit " + fmt.Sprint(limit)
-		start, end = period.Range(time.UTC)
+		query = "select " + activityColumns + " from activities where user_id = ? and (started_at >= ? and started_at <= ?)"
+		args  = []any{userID, start.UTC(), end.UTC()}
 	)
-	rows, err := r.conn.QueryContext(ctx, query, start, end)
+	if conditions, filterArgs := filter.where(""); conditions != "" {
+		query += " and " + conditions
+		args = append(args, filterArgs...)
+	}
+	query += " order by id " + order.String()
+	if limit > 0 {
+		query += " limit " + fmt.Sprint(limit)
+	}
+	rows, err := r.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer ioext.Close(rows)
-	activities := make([]*models.Activity, 0, limit)
-	for rows.Next() {
-		activity := new(models.Activity)
-		err = rows.Scan(
-			&activity.ID,
-			&activity.CategoryID,
-			&activity.Description,
-			&activity.Status,
-			&activity.StartedAt,
-			&activity.UpdatedAt,
-			&activity.FinishedAt,
-		)
-		if err != nil {
-			return activities, err
-		}
-		activities = append(activities, activity)
+	activities, err := scanActivities(rows, limit)
+	if err != nil {
+		return activities, err
+	}
+	return activities, loadDetails(ctx, r.conn, activities...)
+}
+
+// GetOverlapping returns the finished activities of the user whose interval
+// intersects [start, end), ignoring the activity excludeID.
+func (r *activitiesRepository) GetOverlapping(ctx context.Context, userID int64, start, end time.Time, excludeID int64) ([]*models.Activity, error) {
+	return getOverlapping(ctx, r.conn, userID, start, end, excludeID)
+}
+
+func getOverlapping(ctx context.Context, conn queryer, userID int64, start, end time.Time, excludeID int64) ([]*models.Activity, error) {
+	query := "select " + activityColumns + " from activities where user_id = ? and status = ? and started_at < ? and finished_at > ? and id <> ? order by started_at"
+	rows, err := conn.QueryContext(ctx, query, userID, models.Finished, end.UTC(), start.UTC(), excludeID)
+	if err != nil {
+		return nil, err
 	}
-	return activities, err
+	return scanActivities(rows, 1)
 }
 
 func (r *activitiesRepository) Truncate(ctx context.Context) error {
-	query := "delete from activities"
-	_, err := r.conn.ExecContext(ctx, query)
-	return err
+	for _, query := range []string{
+		"delete from activity_changes",
+		"delete from activity_intervals",
+		"delete from activity_tags",
+		"delete from activities",
+	} {
+		if _, err := r.conn.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *activitiesRepository) Close() {
@@ -198,3 +524,48 @@ func (r *activitiesRepository) Close() {
 	ioext.Close(r.updateStmt)
 	ioext.Close(r.deleteStmt)
 }
+
+// loadDetails fills the work intervals and tags of the given activities.
+func loadDetails(ctx context.Context, conn queryer, activities ...*models.Activity) error {
+	if err := loadIntervals(ctx, conn, activities...); err != nil {
+		return err
+	}
+	return loadTags(ctx, conn, activities...)
+}
+
+// running returns the value of the running flag of the activity, the id of
+// its user while it is started and null otherwise. The flag is unique, so
+// each user can only have one activity with it set.
+func running(activity *models.Activity) sql.NullInt64 {
+	return sql.NullInt64{Int64: activity.UserID, Valid: activity.Status == models.Started}
+}
+
+func scanActivities(rows *sql.Rows, capacity int) ([]*models.Activity, error) {
+	defer ioext.Close(rows)
+	activities := make([]*models.Activity, 0, capacity)
+	for rows.Next() {
+		activity := new(models.Activity)
+		if err := rows.Scan(activityFields(activity)...); err != nil {
+			return activities, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+// activityFields returns the destinations of the activityColumns of activity.
+func activityFields(activity *models.Activity) []any {
+	return []any{
+		&activity.ID,
+		&activity.UserID,
+		&activity.CategoryID,
+		&activity.ProjectID,
+		&activity.Billable,
+		&activity.HourlyRate,
+		&activity.Description,
+		&activity.Status,
+		&activity.StartedAt,
+		&activity.UpdatedAt,
+		&activity.FinishedAt,
+	}
+}
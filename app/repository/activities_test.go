@@ -2,12 +2,15 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"errors"
 	"github.com/ungame/timetrack/app/models"
 	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/ioext"
 	"github.com/ungame/timetrack/queries"
 	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -17,16 +20,17 @@ func TestActivitiesRepository_FilterByPeriod(t *testing.T) {
 
 	var (
 		ctx      = context.Background()
-		conn     = db.New()
-		repo     = NewActivitiesRepository(conn)
+		conn     = newTestConn(t)
+		repo     = NewActivitiesRepository(conn, db.SQLite)
 		testTime = time.Date(2022, time.November, 01, 12, 0, 0, 0, time.UTC)
+		clock    = timeext.NewFrozenClock(testTime)
 		err      error
 	)
 
-	defer ioext.Close(conn)
 	defer repo.Close()
 
 	finishedActivity := &models.Activity{
+		UserID:      types.DefaultUserID,
 		CategoryID:  5,
 		Description: "YESTERDAY",
 		Status:      models.Finished,
@@ -43,10 +47,8 @@ func TestActivitiesRepository_FilterByPeriod(t *testing.T) {
 		t.Error(err)
 	}
 
-	// try cleanup tests activities from db
-	defer func() { _, _ = repo.Delete(ctx, finishedActivity.ID) }()
-
 	startedActivity := &models.Activity{
+		UserID:      types.DefaultUserID,
 		CategoryID:  1,
 		Description: "TODAY",
 		Status:      models.Started,
@@ -54,21 +56,15 @@ func TestActivitiesRepository_FilterByPeriod(t *testing.T) {
 	startedActivity.SetStartedAt(testTime)
 	startedActivity.SetUpdatedAt(testTime)
 
-	todayPeriod := _TestPeriod{
-		start: timeext.GetStartOfDayFrom(testTime, testTime.Location()),
-		end:   testTime,
-	}
+	todayPeriod := newTestPeriod(clock, queries.Today)
 
 	startedActivity, err = repo.Create(ctx, startedActivity)
 	if err != nil {
 		t.Error(err)
 	}
 
-	// try cleanup tests activities from db
-	defer func() { _, _ = repo.Delete(ctx, startedActivity.ID) }()
-
 	// starting tests
-	items, err := repo.FilterByPeriod(ctx, &todayPeriod, queries.Desc, 10)
+	items, err := repo.FilterByPeriod(ctx, types.DefaultUserID, todayPeriod.start, todayPeriod.end, nil, queries.Desc, 10)
 	if err != nil {
 		t.Error(err)
 	}
@@ -104,12 +100,9 @@ func TestActivitiesRepository_FilterByPeriod(t *testing.T) {
 	}
 
 	// start testing yesterday items only
-	yesterdayPeriod := _TestPeriod{
-		start: timeext.GetStartOfDayFrom(yesterday, testTime.Location()),
-		end:   timeext.GetEndOfDayFrom(yesterday, testTime.Location()),
-	}
+	yesterdayPeriod := newTestPeriod(clock, queries.Yesterday)
 
-	items, err = repo.FilterByPeriod(ctx, &yesterdayPeriod, queries.Desc, 10)
+	items, err = repo.FilterByPeriod(ctx, types.DefaultUserID, yesterdayPeriod.start, yesterdayPeriod.end, nil, queries.Desc, 10)
 	if err != nil {
 		t.Error(err)
 	}
@@ -147,16 +140,79 @@ func TestActivitiesRepository_FilterByPeriod(t *testing.T) {
 	}
 }
 
+func TestActivitiesRepository_StartPerUser(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn      = newTestConn(t)
+		repo      = NewActivitiesRepository(conn, db.SQLite)
+		users     = NewUsersRepository(conn, db.SQLite)
+		startedAt = time.Date(2022, time.November, 1, 9, 0, 0, 0, time.UTC)
+	)
+	defer repo.Close()
+
+	other, err := users.Create(ctx, &models.User{Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := func(userID int64, description string) (*models.Activity, []*models.Activity) {
+		t.Helper()
+		activity := &models.Activity{UserID: userID, CategoryID: 1, Description: description, Status: models.Started}
+		activity.SetStartedAt(startedAt)
+		activity.SetUpdatedAt(startedAt)
+		activity, finished, err := repo.Start(ctx, activity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return activity, finished
+	}
+
+	first, _ := start(types.DefaultUserID, "first")
+	second, finished := start(other.ID, "second")
+	if len(finished) != 0 {
+		t.Fatalf("unexpected activities finished by another user: %+v", finished)
+	}
+	_, finished = start(types.DefaultUserID, "third")
+	if len(finished) != 1 || finished[0].ID != first.ID {
+		t.Fatalf("unexpected finished activities: expected=[%d], got=%+v", first.ID, finished)
+	}
+
+	running, err := repo.GetByStatus(ctx, other.ID, models.Started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 1 || running[0].ID != second.ID {
+		t.Errorf("unexpected running activities of the other user: %+v", running)
+	}
+
+	if _, err = repo.Get(ctx, types.DefaultUserID, second.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unexpected error on get of another user: expected=%v, got=%v", sql.ErrNoRows, err)
+	}
+	if rows, err := repo.Delete(ctx, types.DefaultUserID, second.ID); err != nil || rows != 0 {
+		t.Errorf("unexpected delete of another user: rows=%d, err=%v", rows, err)
+	}
+}
+
 type _TestPeriod struct {
 	start time.Time
 	end   time.Time
 }
 
-func (t *_TestPeriod) Range(_ *time.Location) (time.Time, time.Time) {
-	return t.start, t.end
+func newTestPeriod(clock timeext.Clock, period queries.Period) _TestPeriod {
+	start, end := period.Range(clock, time.UTC)
+	return _TestPeriod{start: start, end: end}
 }
 
-func (t *_TestPeriod) Print() {
-	fmt.Println("Start:", t.start.Format(timeext.DateTimeFormat))
-	fmt.Println("End:", t.end.Format(timeext.DateTimeFormat))
+// newTestConn opens a SQLite database in a temporary directory, so that tests
+// don't depend on the MySQL instance nor on each other.
+func newTestConn(t *testing.T) *sql.DB {
+	t.Helper()
+
+	var (
+		storage = db.NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+		conn    = db.Lite(storage, db.Migrations(db.SQLite))
+	)
+	t.Cleanup(func() { ioext.Close(conn) })
+
+	return conn
 }
@@ -4,20 +4,53 @@ import (
 	"context"
 	"database/sql"
 	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/ioext"
 )
 
+const (
+	createCategoryQuery             = "insert into categories (name, description, hourly_rate, created_at, updated_at) values (?, ?, ?, ?, ?)"
+	updateCategoryQuery             = "update categories set name = ?, description = ?, hourly_rate = ?, updated_at = ? where id = ?"
+	deleteCategoryQuery             = "delete from categories where id = ?"
+	countCategoryActivitiesQuery    = "select count(*) from activities where category_id = ?"
+	reassignCategoryActivitiesQuery = "update activities set category_id = ? where category_id = ?"
+)
+
 type CategoriesRepository interface {
+	Create(ctx context.Context, category *models.Category) (*models.Category, error)
 	Get(ctx context.Context, id int64) (*models.Category, error)
 	GetAll(ctx context.Context) ([]*models.Category, error)
+	Update(ctx context.Context, category *models.Category) (*models.Category, error)
+	Delete(ctx context.Context, id int64) (deleted int64, activities int64, err error)
+	Replace(ctx context.Context, id, replacementID int64) (int64, error)
 }
 
 type categoriesRepository struct {
-	conn *sql.DB
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewCategoriesRepository(conn *sql.DB, dialect db.Dialect) CategoriesRepository {
+	return &categoriesRepository{conn: conn, dialect: dialect}
 }
 
-func NewCategoriesRepository(conn *sql.DB) CategoriesRepository {
-	return &categoriesRepository{conn: conn}
+func (r *categoriesRepository) Create(ctx context.Context, category *models.Category) (*models.Category, error) {
+	id, err := insert(
+		ctx,
+		r.conn,
+		r.dialect,
+		createCategoryQuery,
+		category.Name,
+		category.Description,
+		category.HourlyRate,
+		category.CreatedAt,
+		category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.ID = id
+	return category, nil
 }
 
 func (r *categoriesRepository) Get(ctx context.Context, id int64) (*models.Category, error) {
@@ -32,6 +65,7 @@ func (r *categoriesRepository) Get(ctx context.Context, id int64) (*models.Categ
 		&category.Description,
 		&category.CreatedAt,
 		&category.UpdatedAt,
+		&category.HourlyRate,
 	)
 	return category, err
 }
@@ -52,6 +86,7 @@ func (r *categoriesRepository) GetAll(ctx context.Context) ([]*models.Category,
 			&category.Description,
 			&category.CreatedAt,
 			&category.UpdatedAt,
+			&category.HourlyRate,
 		)
 		if err != nil {
 			return categories, err
@@ -60,3 +95,49 @@ func (r *categoriesRepository) GetAll(ctx context.Context) ([]*models.Category,
 	}
 	return categories, nil
 }
+
+func (r *categoriesRepository) Update(ctx context.Context, category *models.Category) (*models.Category, error) {
+	_, err := r.conn.ExecContext(
+		ctx,
+		updateCategoryQuery,
+		category.Name,
+		category.Description,
+		category.HourlyRate,
+		category.UpdatedAt,
+		category.ID,
+	)
+	return category, err
+}
+
+// Delete removes the category id unless activities reference it, as
+// deleteUnreferenced does. It returns the number of deleted categories and of
+// the activities that prevented the deletion.
+func (r *categoriesRepository) Delete(ctx context.Context, id int64) (int64, int64, error) {
+	return deleteUnreferenced(ctx, r.conn, countCategoryActivitiesQuery, deleteCategoryQuery, id)
+}
+
+// Replace moves every activity of the category id to replacementID and deletes
+// the category in a single transaction.
+func (r *categoriesRepository) Replace(ctx context.Context, id, replacementID int64) (int64, error) {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, reassignCategoryActivitiesQuery, replacementID, id); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, deleteCategoryQuery, id)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
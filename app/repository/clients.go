@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+)
+
+const (
+	createClientQuery        = "insert into clients (name, description, created_at, updated_at) values (?, ?, ?, ?)"
+	updateClientQuery        = "update clients set name = ?, description = ?, updated_at = ? where id = ?"
+	deleteClientQuery        = "delete from clients where id = ?"
+	countClientProjectsQuery = "select count(*) from projects where client_id = ?"
+)
+
+type ClientsRepository interface {
+	Create(ctx context.Context, client *models.Client) (*models.Client, error)
+	Get(ctx context.Context, id int64) (*models.Client, error)
+	GetAll(ctx context.Context) ([]*models.Client, error)
+	Update(ctx context.Context, client *models.Client) (*models.Client, error)
+	Delete(ctx context.Context, id int64) (int64, int64, error)
+}
+
+type clientsRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewClientsRepository(conn *sql.DB, dialect db.Dialect) ClientsRepository {
+	return &clientsRepository{conn: conn, dialect: dialect}
+}
+
+func (r *clientsRepository) Create(ctx context.Context, client *models.Client) (*models.Client, error) {
+	id, err := insert(
+		ctx,
+		r.conn,
+		r.dialect,
+		createClientQuery,
+		client.Name,
+		client.Description,
+		client.CreatedAt,
+		client.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	client.ID = id
+	return client, nil
+}
+
+func (r *clientsRepository) Get(ctx context.Context, id int64) (*models.Client, error) {
+	var (
+		query  = "select * from clients where id = ?"
+		row    = r.conn.QueryRowContext(ctx, query, id)
+		client = new(models.Client)
+	)
+	err := row.Scan(
+		&client.ID,
+		&client.Name,
+		&client.Description,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+	return client, err
+}
+
+func (r *clientsRepository) GetAll(ctx context.Context) ([]*models.Client, error) {
+	query := "select * from clients order by name"
+	rows, err := r.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+	clients := make([]*models.Client, 0, 5)
+	for rows.Next() {
+		client := new(models.Client)
+		err = rows.Scan(
+			&client.ID,
+			&client.Name,
+			&client.Description,
+			&client.CreatedAt,
+			&client.UpdatedAt,
+		)
+		if err != nil {
+			return clients, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+func (r *clientsRepository) Update(ctx context.Context, client *models.Client) (*models.Client, error) {
+	_, err := r.conn.ExecContext(
+		ctx,
+		updateClientQuery,
+		client.Name,
+		client.Description,
+		client.UpdatedAt,
+		client.ID,
+	)
+	return client, err
+}
+
+// Delete removes the client id unless projects reference it, as
+// deleteUnreferenced does. It returns the number of deleted clients and of the
+// projects that prevented the deletion.
+func (r *clientsRepository) Delete(ctx context.Context, id int64) (int64, int64, error) {
+	return deleteUnreferenced(ctx, r.conn, countClientProjectsQuery, deleteClientQuery, id)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import "strings"
+
+// ActivityFilter narrows activities down to the ones with a tag, of a project
+// or of the projects of a client. Zero fields do not filter.
+type ActivityFilter struct {
+	Tag       string
+	ProjectID int64
+	ClientID  int64
+}
+
+// where returns the conditions of the filter, joined by and, on the
+// activities table referenced by prefix, along with their arguments. It
+// returns an empty condition when there is nothing to filter.
+func (f *ActivityFilter) where(prefix string) (string, []any) {
+	if f == nil {
+		return "", nil
+	}
+
+	var (
+		conditions = make([]string, 0, 3)
+		args       = make([]any, 0, 3)
+	)
+	if f.Tag != "" {
+		conditions = append(conditions, prefix+"id in ("+taggedActivities+")")
+		args = append(args, f.Tag)
+	}
+	if f.ProjectID > 0 {
+		conditions = append(conditions, prefix+"project_id = ?")
+		args = append(args, f.ProjectID)
+	}
+	if f.ClientID > 0 {
+		conditions = append(conditions, prefix+"project_id in (select id from projects where client_id = ?)")
+		args = append(args, f.ClientID)
+	}
+	return strings.Join(conditions, " and "), args
+}
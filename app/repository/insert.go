@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/db"
+)
+
+type inserter interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insert runs the insert query of a row with an id column and returns the
+// id, read with RETURNING on Postgres, which has no LastInsertId.
+func insert(ctx context.Context, conn inserter, dialect db.Dialect, query string, args ...any) (int64, error) {
+	if dialect == db.Postgres {
+		var id int64
+		err := conn.QueryRowContext(ctx, dialect.ReturningID(query), args...).Scan(&id)
+		return id, err
+	}
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// insertStmt runs an insert statement prepared with the query of
+// db.Dialect.ReturningID and returns the id, as insert does.
+func insertStmt(ctx context.Context, stmt *sql.Stmt, dialect db.Dialect, args ...any) (int64, error) {
+	if dialect == db.Postgres {
+		var id int64
+		err := stmt.QueryRowContext(ctx, args...).Scan(&id)
+		return id, err
+	}
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+	"strings"
+	"time"
+)
+
+const (
+	createIntervalQuery  = "insert into activity_intervals (activity_id, started_at, finished_at) values (?, ?, ?)"
+	updateIntervalQuery  = "update activity_intervals set started_at = ?, finished_at = ? where id = ?"
+	closeIntervalQuery   = "update activity_intervals set finished_at = ? where activity_id = ? and finished_at is null"
+	deleteIntervalsQuery = "delete from activity_intervals where activity_id = ?"
+
+	// maxQueryBatch keeps the number of placeholders of a single query far
+	// below the limits of the supported databases.
+	maxQueryBatch = 500
+)
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func createInterval(ctx context.Context, tx *sql.Tx, dialect db.Dialect, interval *models.ActivityInterval) error {
+	var err error
+	interval.ID, err = insert(
+		ctx,
+		tx,
+		dialect,
+		createIntervalQuery,
+		interval.ActivityID,
+		interval.StartedAt,
+		interval.FinishedAt,
+	)
+	return err
+}
+
+func updateInterval(ctx context.Context, tx *sql.Tx, interval *models.ActivityInterval) error {
+	_, err := tx.ExecContext(
+		ctx,
+		updateIntervalQuery,
+		interval.StartedAt,
+		interval.FinishedAt,
+		interval.ID,
+	)
+	return err
+}
+
+// closeInterval finishes the open work interval of the activity at the given
+// time. Activities started before work intervals existed get a single
+// interval covering the whole time since they started.
+func closeInterval(ctx context.Context, tx *sql.Tx, dialect db.Dialect, activity *models.Activity, at time.Time) error {
+	if len(activity.Intervals) == 0 {
+		interval := &models.ActivityInterval{
+			ActivityID: activity.ID,
+			StartedAt:  activity.StartedAt,
+		}
+		interval.SetFinishedAt(at)
+		activity.Intervals = []*models.ActivityInterval{interval}
+		return createInterval(ctx, tx, dialect, interval)
+	}
+
+	if _, err := tx.ExecContext(ctx, closeIntervalQuery, at.UTC(), activity.ID); err != nil {
+		return err
+	}
+
+	for _, interval := range activity.Intervals {
+		if !interval.FinishedAt.Valid {
+			interval.SetFinishedAt(at)
+		}
+	}
+
+	return nil
+}
+
+// loadIntervals fills the work intervals of the given activities.
+func loadIntervals(ctx context.Context, conn queryer, activities ...*models.Activity) error {
+	byID := make(map[int64]*models.Activity, len(activities))
+	for _, activity := range activities {
+		byID[activity.ID] = activity
+	}
+
+	return inBatches(activities, func(placeholders string, args []any) error {
+		query := "select * from activity_intervals where activity_id in (" + placeholders + ") order by started_at, id"
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		return scanIntervals(rows, byID)
+	})
+}
+
+// inBatches calls fn with the placeholders and IDs of the activities, in
+// batches of at most maxQueryBatch.
+func inBatches(activities []*models.Activity, fn func(placeholders string, args []any) error) error {
+	for start := 0; start < len(activities); start += maxQueryBatch {
+		end := start + maxQueryBatch
+		if end > len(activities) {
+			end = len(activities)
+		}
+
+		var (
+			batch        = activities[start:end]
+			placeholders = make([]string, 0, len(batch))
+			args         = make([]any, 0, len(batch))
+		)
+		for _, activity := range batch {
+			placeholders = append(placeholders, "?")
+			args = append(args, activity.ID)
+		}
+
+		if err := fn(strings.Join(placeholders, ", "), args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanIntervals(rows *sql.Rows, activities map[int64]*models.Activity) error {
+	defer ioext.Close(rows)
+	for rows.Next() {
+		interval := new(models.ActivityInterval)
+		err := rows.Scan(
+			&interval.ID,
+			&interval.ActivityID,
+			&interval.StartedAt,
+			&interval.FinishedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if activity, ok := activities[interval.ActivityID]; ok {
+			activity.Intervals = append(activity.Intervals, interval)
+		}
+	}
+	return rows.Err()
+}
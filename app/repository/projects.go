@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+)
+
+const (
+	createProjectQuery          = "insert into projects (client_id, name, description, created_at, updated_at) values (?, ?, ?, ?, ?)"
+	updateProjectQuery          = "update projects set client_id = ?, name = ?, description = ?, updated_at = ? where id = ?"
+	deleteProjectQuery          = "delete from projects where id = ?"
+	countProjectActivitiesQuery = "select count(*) from activities where project_id = ?"
+)
+
+type ProjectsRepository interface {
+	Create(ctx context.Context, project *models.Project) (*models.Project, error)
+	Get(ctx context.Context, id int64) (*models.Project, error)
+	GetAll(ctx context.Context, clientID int64) ([]*models.Project, error)
+	Update(ctx context.Context, project *models.Project) (*models.Project, error)
+	Delete(ctx context.Context, id int64) (int64, int64, error)
+}
+
+type projectsRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewProjectsRepository(conn *sql.DB, dialect db.Dialect) ProjectsRepository {
+	return &projectsRepository{conn: conn, dialect: dialect}
+}
+
+func (r *projectsRepository) Create(ctx context.Context, project *models.Project) (*models.Project, error) {
+	id, err := insert(
+		ctx,
+		r.conn,
+		r.dialect,
+		createProjectQuery,
+		project.ClientID,
+		project.Name,
+		project.Description,
+		project.CreatedAt,
+		project.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	project.ID = id
+	return project, nil
+}
+
+func (r *projectsRepository) Get(ctx context.Context, id int64) (*models.Project, error) {
+	var (
+		query   = "select * from projects where id = ?"
+		row     = r.conn.QueryRowContext(ctx, query, id)
+		project = new(models.Project)
+	)
+	err := row.Scan(
+		&project.ID,
+		&project.ClientID,
+		&project.Name,
+		&project.Description,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+	return project, err
+}
+
+// GetAll returns every project, or only the ones of the client if clientID
+// is set.
+func (r *projectsRepository) GetAll(ctx context.Context, clientID int64) ([]*models.Project, error) {
+	var (
+		query = "select * from projects"
+		args  []any
+	)
+	if clientID > 0 {
+		query += " where client_id = ?"
+		args = append(args, clientID)
+	}
+	rows, err := r.conn.QueryContext(ctx, query+" order by name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+	projects := make([]*models.Project, 0, 5)
+	for rows.Next() {
+		project := new(models.Project)
+		err = rows.Scan(
+			&project.ID,
+			&project.ClientID,
+			&project.Name,
+			&project.Description,
+			&project.CreatedAt,
+			&project.UpdatedAt,
+		)
+		if err != nil {
+			return projects, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *projectsRepository) Update(ctx context.Context, project *models.Project) (*models.Project, error) {
+	_, err := r.conn.ExecContext(
+		ctx,
+		updateProjectQuery,
+		project.ClientID,
+		project.Name,
+		project.Description,
+		project.UpdatedAt,
+		project.ID,
+	)
+	return project, err
+}
+
+// Delete removes the project id unless activities reference it, as
+// deleteUnreferenced does. It returns the number of deleted projects and of
+// the activities that prevented the deletion.
+func (r *projectsRepository) Delete(ctx context.Context, id int64) (int64, int64, error) {
+	return deleteUnreferenced(ctx, r.conn, countProjectActivitiesQuery, deleteProjectQuery, id)
+}
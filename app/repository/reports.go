@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportsRepository summarizes the activities of a user.
+type ReportsRepository interface {
+	Summarize(ctx context.Context, userID int64, start, end, now time.Time, location *time.Location, filter *ActivityFilter) ([]*models.CategoryDaySummary, error)
+	SummarizeTags(ctx context.Context, userID int64, start, end, now time.Time, filter *ActivityFilter) ([]*models.TagSummary, error)
+	Billable(ctx context.Context, userID int64, start, end, now time.Time, filter *ActivityFilter) ([]*models.BillableEntry, error)
+}
+
+type reportsRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewReportsRepository(conn *sql.DB, dialect db.Dialect) ReportsRepository {
+	return &reportsRepository{conn: conn, dialect: dialect}
+}
+
+// Summarize returns the activities started within [start, end] grouped by the
+// day they started on in location and by category, with the seconds of their
+// work intervals, ordered by day and category name. Open intervals count until
+// now and activities recorded before work intervals existed count from start
+// to finish. When filter is set only its activities are summarized.
+func (r *reportsRepository) Summarize(ctx context.Context, userID int64, start, end, now time.Time, location *time.Location, filter *ActivityFilter) ([]*models.CategoryDaySummary, error) {
+	var (
+		offset, args = offsetOf(start, end, location)
+		where, more  = periodWhere(userID, start, end, now, filter)
+		query        = fmt.Sprintf(`select %s as day_number, a.category_id, c.name, count(distinct a.id), coalesce(sum(%s), 0)
+			from activities a
+			join categories c on c.id = a.category_id
+			left join activity_intervals i on i.activity_id = a.id
+			where %s
+			group by day_number, a.category_id, c.name
+			order by day_number, c.name, a.category_id`, r.dialect.LocalDays("a.started_at", offset), r.workedSeconds(), where)
+	)
+	rows, err := r.conn.QueryContext(ctx, query, append(args, more...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+
+	summaries := make([]*models.CategoryDaySummary, 0, 10)
+	for rows.Next() {
+		var (
+			summary = new(models.CategoryDaySummary)
+			days    int64
+		)
+		err = rows.Scan(
+			&days,
+			&summary.CategoryID,
+			&summary.Category,
+			&summary.Count,
+			&summary.Seconds,
+		)
+		if err != nil {
+			return summaries, err
+		}
+		summary.Date = time.Unix(days*86400, 0).UTC().Format(timeext.DateOnlyFormat)
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// SummarizeTags returns the activities started within [start, end] grouped by
+// tag, counted as in Summarize. When filter is set only its activities are
+// summarized, e.g. the ones with a tag along with their other tags.
+func (r *reportsRepository) SummarizeTags(ctx context.Context, userID int64, start, end, now time.Time, filter *ActivityFilter) ([]*models.TagSummary, error) {
+	var (
+		where, args = periodWhere(userID, start, end, now, filter)
+		query       = fmt.Sprintf(`select t.name, count(distinct a.id), coalesce(sum(%s), 0)
+			from activities a
+			join activity_tags l on l.activity_id = a.id
+			join tags t on t.id = l.tag_id
+			left join activity_intervals i on i.activity_id = a.id
+			where %s
+			group by t.name
+			order by t.name`, r.workedSeconds(), where)
+	)
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+
+	summaries := make([]*models.TagSummary, 0, 10)
+	for rows.Next() {
+		summary := new(models.TagSummary)
+		if err = rows.Scan(&summary.Tag, &summary.Count, &summary.Seconds); err != nil {
+			return summaries, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// Billable returns the finished billable activities started within
+// [start, end], with the seconds counted as in Summarize and the hourly rate
+// of the activity, or else of its category, ordered by category.
+func (r *reportsRepository) Billable(ctx context.Context, userID int64, start, end, now time.Time, filter *ActivityFilter) ([]*models.BillableEntry, error) {
+	where, args := periodWhere(userID, start, end, now, filter)
+	where += " and a.billable = ? and a.finished_at is not null"
+	args = append(args, true)
+
+	query := fmt.Sprintf(`select a.id, a.category_id, c.name, coalesce(a.hourly_rate, c.hourly_rate), coalesce(sum(%s), 0)
+		from activities a
+		join categories c on c.id = a.category_id
+		left join activity_intervals i on i.activity_id = a.id
+		where %s
+		group by a.id, a.category_id, c.name, a.hourly_rate, c.hourly_rate
+		order by c.name, a.id`, r.workedSeconds(), where)
+
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+
+	entries := make([]*models.BillableEntry, 0, 10)
+	for rows.Next() {
+		entry := new(models.BillableEntry)
+		err = rows.Scan(
+			&entry.ActivityID,
+			&entry.CategoryID,
+			&entry.Category,
+			&entry.HourlyRate,
+			&entry.Seconds,
+		)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// workedSeconds returns an expression of the seconds of a work interval i of
+// the activity a, whose first placeholder is the time open intervals count
+// until.
+func (r *reportsRepository) workedSeconds() string {
+	return r.dialect.SecondsBetween(
+		"coalesce(i.started_at, a.started_at)",
+		"coalesce(i.finished_at, a.finished_at, ?)",
+	)
+}
+
+// offsetOf returns an expression of the offset of location from UTC, in
+// seconds, at the start of an activity a within [start, end], which changes
+// along the period when location observes daylight saving time, and the
+// arguments of the expression.
+func offsetOf(start, end time.Time, location *time.Location) (string, []any) {
+	var (
+		offsets = timeext.Offsets(start, end, location)
+		last    = offsets[len(offsets)-1]
+	)
+	if len(offsets) == 1 {
+		return strconv.Itoa(last.Seconds), nil
+	}
+
+	var (
+		expression = strings.Builder{}
+		args       = make([]any, 0, len(offsets)-1)
+	)
+	expression.WriteString("case")
+	for i, offset := range offsets[:len(offsets)-1] {
+		fmt.Fprintf(&expression, " when a.started_at < ? then %d", offset.Seconds)
+		args = append(args, offsets[i+1].From.UTC())
+	}
+	fmt.Fprintf(&expression, " else %d end", last.Seconds)
+	return expression.String(), args
+}
+
+// periodWhere returns the condition on the activities a of the user started
+// within [start, end] and of the filter, if set, and the arguments of the
+// query, starting with now for workedSeconds.
+func periodWhere(userID int64, start, end, now time.Time, filter *ActivityFilter) (string, []any) {
+	var (
+		where = "a.user_id = ? and a.started_at >= ? and a.started_at <= ?"
+		args  = []any{now.UTC(), userID, start.UTC(), end.UTC()}
+	)
+	if conditions, filterArgs := filter.where("a."); conditions != "" {
+		where += " and " + conditions
+		args = append(args, filterArgs...)
+	}
+	return where, args
+}
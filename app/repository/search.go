@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+	"strings"
+	"time"
+)
+
+type SearchRepository interface {
+	Search(ctx context.Context, userID int64, terms []string, start, end time.Time, filter *ActivityFilter, limit int) ([]*models.SearchHit, error)
+}
+
+type searchRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+	fts     bool
+}
+
+// NewSearchRepository creates the search repository, which matches
+// descriptions with the FULLTEXT index on MySQL and with the FTS5 index in lite
+// mode, or else with LIKE, as on Postgres.
+func NewSearchRepository(conn *sql.DB, dialect db.Dialect) SearchRepository {
+	return &searchRepository{
+		conn:    conn,
+		dialect: dialect,
+		fts:     dialect == db.MySQL || (dialect == db.SQLite && db.HasSearchIndex(conn)),
+	}
+}
+
+// Search returns up to limit activities of the user whose description has
+// any of the terms, or with a tag or category named as one of them, from the
+// best match. Each term in the description, tag and category named as a term
+// scores one, and the rank of the description by the database, below one,
+// breaks ties. Activities are only searched within [start, end] unless they
+// are zero, and within the filter if it is set.
+func (r *searchRepository) Search(ctx context.Context, userID int64, terms []string, start, end time.Time, filter *ActivityFilter, limit int) ([]*models.SearchHit, error) {
+	var (
+		names              = placeholders(len(terms))
+		matches, matchArgs = r.matchDescription(terms)
+		found              = make([]string, 0, len(terms))
+		args               = make([]any, 0, 6*len(terms)+6)
+	)
+	for range terms {
+		found = append(found, "(case when lower(a.description) like ? escape '"+likeEscape+"' then 1 else 0 end)")
+	}
+
+	query := fmt.Sprintf(`select %s, c.name, %s
+			+ coalesce(d.score / (1 + d.score), 0)
+			+ (case when c.name in (%s) then 1 else 0 end)
+			+ (select count(*) from activity_tags l join tags t on t.id = l.tag_id where l.activity_id = a.id and t.name in (%s)) as score
+		from activities a
+		join categories c on c.id = a.category_id
+		left join (%s) d on d.id = a.id
+		where a.user_id = ? and (d.id is not null
+			or c.name in (%s)
+			or a.id in (select l.activity_id from activity_tags l join tags t on t.id = l.tag_id where t.name in (%s)))`,
+		prefixed("a.", activityColumns), strings.Join(found, " + "), names, names, matches, names, names)
+	for _, term := range terms {
+		args = append(args, containing(term))
+	}
+	args = appendTerms(args, terms)
+	args = appendTerms(args, terms)
+	args = append(args, matchArgs...)
+	args = append(args, userID)
+	args = appendTerms(args, terms)
+	args = appendTerms(args, terms)
+
+	if !start.IsZero() {
+		query += " and a.started_at >= ?"
+		args = append(args, start.UTC())
+	}
+	if !end.IsZero() {
+		query += " and a.started_at <= ?"
+		args = append(args, end.UTC())
+	}
+	if conditions, filterArgs := filter.where("a."); conditions != "" {
+		query += " and " + conditions
+		args = append(args, filterArgs...)
+	}
+	query += " order by score desc, a.started_at desc, a.id desc limit ?"
+	args = append(args, limit)
+
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+
+	var (
+		hits       = make([]*models.SearchHit, 0, limit)
+		activities = make([]*models.Activity, 0, limit)
+	)
+	for rows.Next() {
+		hit := &models.SearchHit{Activity: new(models.Activity)}
+		if err = rows.Scan(append(activityFields(hit.Activity), &hit.Category, &hit.Score)...); err != nil {
+			return hits, err
+		}
+		hits = append(hits, hit)
+		activities = append(activities, hit.Activity)
+	}
+	if err = rows.Err(); err != nil {
+		return hits, err
+	}
+	return hits, loadDetails(ctx, r.conn, activities...)
+}
+
+// matchDescription returns a query of the id and rank of the activities
+// whose description has any of the terms, and its arguments. The rank is at
+// least zero and higher for better matches, and zero without an index.
+func (r *searchRepository) matchDescription(terms []string) (string, []any) {
+	switch {
+	case r.fts && r.dialect == db.SQLite:
+		// bm25 is lower for better matches
+		quoted := make([]string, 0, len(terms))
+		for _, term := range terms {
+			quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+		}
+		query := fmt.Sprintf("select rowid as id, -bm25(%s) as score from %s where %s match ?", db.SearchTable, db.SearchTable, db.SearchTable)
+		return query, []any{strings.Join(quoted, " OR ")}
+
+	case r.fts:
+		against := strings.Join(terms, " ")
+		query := "select id, match(description) against (?) as score from activities where match(description) against (?)"
+		return query, []any{against, against}
+
+	default:
+		var (
+			conditions = make([]string, 0, len(terms))
+			args       = make([]any, 0, len(terms))
+		)
+		for _, term := range terms {
+			conditions = append(conditions, "lower(description) like ? escape '"+likeEscape+"'")
+			args = append(args, containing(term))
+		}
+		query := "select id, 0 as score from activities where " + strings.Join(conditions, " or ")
+		return query, args
+	}
+}
+
+// likeEscape escapes the wildcards of LIKE patterns. Unlike a backslash, it
+// needs no escaping in the string literals of any dialect.
+const likeEscape = "!"
+
+var likeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// containing returns a LIKE pattern, escaped with likeEscape, of the values
+// containing term, whose % and _ match themselves.
+func containing(term string) string {
+	return "%" + likeReplacer.Replace(term) + "%"
+}
+
+func appendTerms(args []any, terms []string) []any {
+	for _, term := range terms {
+		args = append(args, term)
+	}
+	return args
+}
+
+// prefixed returns the comma separated columns with the table prefix.
+func prefixed(prefix, columns string) string {
+	return prefix + strings.Join(strings.Split(columns, ", "), ", "+prefix)
+}
+
+// placeholders returns n comma separated placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
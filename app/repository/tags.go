@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+)
+
+const (
+	getTagQuery             = "select id from tags where name = ?"
+	createTagQuery          = "insert into tags (name) values (?)"
+	createActivityTagQuery  = "insert into activity_tags (activity_id, tag_id) values (?, ?)"
+	deleteActivityTagsQuery = "delete from activity_tags where activity_id = ?"
+
+	// taggedActivities selects the IDs of the activities with the tag bound
+	// to its placeholder.
+	taggedActivities = "select activity_tags.activity_id from activity_tags join tags on tags.id = activity_tags.tag_id where tags.name = ?"
+)
+
+// saveTags replaces the tags of the activity, creating the ones that do not
+// exist yet.
+func saveTags(ctx context.Context, tx *sql.Tx, dialect db.Dialect, activity *models.Activity) error {
+	if _, err := tx.ExecContext(ctx, deleteActivityTagsQuery, activity.ID); err != nil {
+		return err
+	}
+
+	for _, tag := range activity.Tags {
+		var id int64
+		err := tx.QueryRowContext(ctx, getTagQuery, tag).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			id, err = insert(ctx, tx, dialect, createTagQuery, tag)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, createActivityTagQuery, activity.ID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags fills the tags of the given activities, sorted by name.
+func loadTags(ctx context.Context, conn queryer, activities ...*models.Activity) error {
+	byID := make(map[int64]*models.Activity, len(activities))
+	for _, activity := range activities {
+		byID[activity.ID] = activity
+	}
+
+	return inBatches(activities, func(placeholders string, args []any) error {
+		query := "select activity_tags.activity_id, tags.name from activity_tags join tags on tags.id = activity_tags.tag_id where activity_tags.activity_id in (" + placeholders + ") order by tags.name"
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer ioext.Close(rows)
+
+		for rows.Next() {
+			var (
+				activityID int64
+				tag        string
+			)
+			if err = rows.Scan(&activityID, &tag); err != nil {
+				return err
+			}
+			if activity, ok := byID[activityID]; ok {
+				activity.Tags = append(activity.Tags, tag)
+			}
+		}
+		return rows.Err()
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+)
+
+const (
+	tokenColumns     = "id, user_id, name, hash, scope, created_at, revoked_at"
+	createTokenQuery = "insert into tokens (user_id, name, hash, scope, created_at) values (?, ?, ?, ?, ?)"
+	revokeTokenQuery = "update tokens set revoked_at = ? where id = ? and user_id = ? and revoked_at is null"
+)
+
+type TokensRepository interface {
+	Create(ctx context.Context, token *models.Token) (*models.Token, error)
+	Get(ctx context.Context, userID, id int64) (*models.Token, error)
+	GetByHash(ctx context.Context, hash string) (*models.Token, error)
+	GetAll(ctx context.Context, userID int64) ([]*models.Token, error)
+	Revoke(ctx context.Context, token *models.Token) (int64, error)
+}
+
+type tokensRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewTokensRepository(conn *sql.DB, dialect db.Dialect) TokensRepository {
+	return &tokensRepository{conn: conn, dialect: dialect}
+}
+
+func (r *tokensRepository) Create(ctx context.Context, token *models.Token) (*models.Token, error) {
+	id, err := insert(
+		ctx,
+		r.conn,
+		r.dialect,
+		createTokenQuery,
+		token.UserID,
+		token.Name,
+		token.Hash,
+		token.Scope,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.ID = id
+	return token, nil
+}
+
+// Get returns the token of the user, or sql.ErrNoRows if the token is of
+// another user.
+func (r *tokensRepository) Get(ctx context.Context, userID, id int64) (*models.Token, error) {
+	query := "select " + tokenColumns + " from tokens where id = ? and user_id = ?"
+	return scanToken(r.conn.QueryRowContext(ctx, query, id, userID))
+}
+
+func (r *tokensRepository) GetByHash(ctx context.Context, hash string) (*models.Token, error) {
+	query := "select " + tokenColumns + " from tokens where hash = ?"
+	return scanToken(r.conn.QueryRowContext(ctx, query, hash))
+}
+
+func (r *tokensRepository) GetAll(ctx context.Context, userID int64) ([]*models.Token, error) {
+	query := "select " + tokenColumns + " from tokens where user_id = ? order by id"
+	rows, err := r.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+	tokens := make([]*models.Token, 0, 5)
+	for rows.Next() {
+		token := new(models.Token)
+		if err = rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &token.Scope, &token.CreatedAt, &token.RevokedAt); err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke sets the revoked_at of the token, returning 0 rows if it was
+// already revoked.
+func (r *tokensRepository) Revoke(ctx context.Context, token *models.Token) (int64, error) {
+	result, err := r.conn.ExecContext(ctx, revokeTokenQuery, token.RevokedAt, token.ID, token.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanToken(row *sql.Row) (*models.Token, error) {
+	token := new(models.Token)
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &token.Scope, &token.CreatedAt, &token.RevokedAt)
+	return token, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/db"
+)
+
+// maxConflictRetries bounds how many times a transaction that lost a race
+// against a concurrent one is retried.
+const maxConflictRetries = 5
+
+// serializable transactions fail, and are retried, when a concurrent one
+// changed the rows they read, e.g. the overlapping activities of Add. SQLite
+// ignores it, its immediate transactions are already serialized.
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+// retryOnConflict runs fn in a transaction with the given options, running it
+// again in a new one while it fails because of a concurrent transaction.
+func retryOnConflict(ctx context.Context, conn *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) (err error) {
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		err = inTx(ctx, conn, opts, fn)
+		if err == nil || !db.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func inTx(ctx context.Context, conn *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteUnreferenced deletes the row id with deleteQuery unless countQuery
+// counts rows referencing it, in a serializable transaction, so that no
+// reference is added in between. It returns the number of deleted rows and
+// of the references that prevented the deletion.
+func deleteUnreferenced(ctx context.Context, conn *sql.DB, countQuery, deleteQuery string, id int64) (deleted, references int64, err error) {
+	err = retryOnConflict(ctx, conn, serializable, func(tx *sql.Tx) error {
+		deleted = 0
+		if err := tx.QueryRowContext(ctx, countQuery, id).Scan(&references); err != nil || references > 0 {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, deleteQuery, id)
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	return deleted, references, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+)
+
+const (
+	userColumns     = "id, name, created_at, updated_at"
+	createUserQuery = "insert into users (name, created_at, updated_at) values (?, ?, ?)"
+)
+
+type UsersRepository interface {
+	Create(ctx context.Context, user *models.User) (*models.User, error)
+	Get(ctx context.Context, id int64) (*models.User, error)
+	GetByName(ctx context.Context, name string) (*models.User, error)
+	GetAll(ctx context.Context) ([]*models.User, error)
+}
+
+type usersRepository struct {
+	conn    *sql.DB
+	dialect db.Dialect
+}
+
+func NewUsersRepository(conn *sql.DB, dialect db.Dialect) UsersRepository {
+	return &usersRepository{conn: conn, dialect: dialect}
+}
+
+func (r *usersRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	id, err := insert(
+		ctx,
+		r.conn,
+		r.dialect,
+		createUserQuery,
+		user.Name,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.ID = id
+	return user, nil
+}
+
+func (r *usersRepository) Get(ctx context.Context, id int64) (*models.User, error) {
+	query := "select " + userColumns + " from users where id = ?"
+	return scanUser(r.conn.QueryRowContext(ctx, query, id))
+}
+
+func (r *usersRepository) GetByName(ctx context.Context, name string) (*models.User, error) {
+	query := "select " + userColumns + " from users where name = ?"
+	return scanUser(r.conn.QueryRowContext(ctx, query, name))
+}
+
+func (r *usersRepository) GetAll(ctx context.Context) ([]*models.User, error) {
+	query := "select " + userColumns + " from users order by name"
+	rows, err := r.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(rows)
+	users := make([]*models.User, 0, 5)
+	for rows.Next() {
+		user := new(models.User)
+		if err = rows.Scan(&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return users, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row *sql.Row) (*models.User, error) {
+	user := new(models.User)
+	err := row.Scan(&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
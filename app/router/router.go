@@ -6,6 +6,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/ungame/timetrack/app/handlers"
 	"github.com/ungame/timetrack/app/middlewares"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/config"
 	"net/http"
 )
 
@@ -13,10 +15,17 @@ type Router struct {
 	router *mux.Router
 }
 
-func New(handlers ...handlers.Handler) *Router {
+// New creates the router of the handlers, whose requests are made by the
+// users of usersService. When auth is enabled, the requests must have a
+// token of tokensService, unless their path is exempt.
+func New(auth *config.Auth, usersService service.UsersService, tokensService service.TokensService, handlers ...handlers.Handler) *Router {
 	router := mux.NewRouter().StrictSlash(true)
 
 	router.Use(middlewares.Logger)
+	if auth.Enabled {
+		router.Use(middlewares.Auth(tokensService, usersService, auth.Exempt))
+	}
+	router.Use(middlewares.Users(usersService))
 	router.Handle("/metrics", promhttp.Handler())
 
 	for _, handler := range handlers {
@@ -30,9 +39,23 @@ func (r *Router) Handler() http.Handler {
 	return r.router
 }
 
-func (r *Router) WithCors() http.Handler {
-	methods := muxHandlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete})
-	headers := muxHandlers.AllowedHeaders([]string{"Content-Type", "X-Requested-with", "Accept"})
-	origins := muxHandlers.AllowedOrigins([]string{"*"})
-	return muxHandlers.CORS(methods, headers, origins)(r.router)
+// WithCors returns the handler of the router, which answers the preflight
+// requests of browsers on other origins and sets the CORS headers of the
+// responses by the policy of cors. Without origins, the browsers refuse the
+// responses to other origins.
+func (r *Router) WithCors(cors *config.Cors) http.Handler {
+	if len(cors.Origins) == 0 {
+		return r.router
+	}
+	options := []muxHandlers.CORSOption{
+		muxHandlers.AllowedOrigins(cors.Origins),
+		muxHandlers.AllowedMethods(cors.Methods),
+		muxHandlers.AllowedHeaders(cors.Headers),
+		muxHandlers.ExposedHeaders(cors.ExposedHeaders),
+		muxHandlers.MaxAge(int(cors.MaxAge.Seconds())),
+	}
+	if cors.Credentials {
+		options = append(options, muxHandlers.AllowCredentials())
+	}
+	return muxHandlers.CORS(options...)(r.router)
 }
@@ -0,0 +1,301 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ungame/timetrack/app/handlers"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/config"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRouter_Auth(t *testing.T) {
+	var (
+		ctx                            = context.Background()
+		r, usersService, tokensService = newTestRouter(t)
+		server                         = httptest.NewServer(r.Handler())
+	)
+	t.Cleanup(server.Close)
+
+	alice, err := usersService.CreateUser(ctx, &types.User{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write, err := tokensService.CreateToken(ctx, types.DefaultUserID, &types.Token{Name: "admin", Scope: types.ScopeWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := tokensService.CreateToken(ctx, alice.ID, &types.Token{Name: "laptop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Scope != types.ScopeRead {
+		t.Errorf("unexpected default scope: expected=%s, got=%s", types.ScopeRead, read.Scope)
+	}
+
+	// exempt paths
+	send(t, server, "", http.MethodGet, "/healthz", nil, http.StatusOK, nil)
+	send(t, server, "", http.MethodGet, "/readyz", nil, http.StatusOK, nil)
+	send(t, server, "", http.MethodGet, "/metrics", nil, http.StatusOK, nil)
+
+	res := send(t, server, "", http.MethodGet, "/categories", nil, http.StatusUnauthorized, nil)
+	if res.Header.Get(httpext.HeaderWWWAuthenticate) == "" {
+		t.Error("missing WWW-Authenticate header of unauthorized response")
+	}
+	send(t, server, service.TokenPrefix+"unknown", http.MethodGet, "/categories", nil, http.StatusUnauthorized, nil)
+
+	// the user is the one of the token, and read tokens can't change data
+	me := new(types.User)
+	send(t, server, read.Secret, http.MethodGet, "/users/me", nil, http.StatusOK, me)
+	if me.ID != alice.ID {
+		t.Errorf("unexpected user of the token: expected=%d, got=%d", alice.ID, me.ID)
+	}
+	send(t, server, read.Secret, http.MethodPost, "/categories", &types.Category{Name: "design"}, http.StatusForbidden, nil)
+	send(t, server, write.Secret, http.MethodPost, "/categories", &types.Category{Name: "design"}, http.StatusCreated, nil)
+
+	req := newRequest(t, server, read.Secret, http.MethodGet, "/users/me", nil)
+	req.Header.Set(httpext.HeaderUser, "default")
+	do(t, req, http.StatusForbidden, nil)
+
+	// create, list and revoke tokens
+	created := new(types.Token)
+	send(t, server, write.Secret, http.MethodPost, "/tokens", &types.Token{Name: "ci", Scope: types.ScopeWrite}, http.StatusCreated, created)
+	if created.Secret == "" || created.UserID != types.DefaultUserID {
+		t.Fatalf("unexpected created token: %+v", created)
+	}
+	var tokens []*types.Token
+	send(t, server, created.Secret, http.MethodGet, "/tokens", nil, http.StatusOK, &tokens)
+	if len(tokens) != 2 || tokens[0].Secret != "" {
+		t.Errorf("unexpected tokens of the user: %+v", tokens)
+	}
+
+	path := fmt.Sprintf("/tokens/%d", created.ID)
+	send(t, server, read.Secret, http.MethodDelete, path, nil, http.StatusForbidden, nil)
+	send(t, server, write.Secret, http.MethodDelete, path, nil, http.StatusNoContent, nil)
+	send(t, server, write.Secret, http.MethodDelete, path, nil, http.StatusConflict, nil)
+	send(t, server, created.Secret, http.MethodGet, "/tokens", nil, http.StatusUnauthorized, nil)
+	send(t, server, write.Secret, http.MethodDelete, fmt.Sprintf("/tokens/%d", read.ID), nil, http.StatusNotFound, nil)
+}
+
+func TestRouter_WithCors(t *testing.T) {
+	const dashboard = "http://localhost:3000"
+
+	r, _, _ := newTestRouter(t)
+
+	tests := []struct {
+		name    string
+		cors    func(cors *config.Cors)
+		origin  string
+		method  string
+		headers string
+		status  int
+		expect  map[string]string
+	}{
+		{
+			name:    "default policy allows any origin",
+			origin:  dashboard,
+			method:  http.MethodPatch,
+			headers: "authorization, content-type, x-timezone",
+			status:  http.StatusOK,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Methods":     http.MethodPatch,
+				"Access-Control-Allow-Headers":     "Authorization,Content-Type,X-Timezone",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name: "listed origin with credentials",
+			cors: func(cors *config.Cors) {
+				cors.Origins = config.List{dashboard}
+				cors.Credentials = true
+				cors.MaxAge = time.Minute
+			},
+			origin:  dashboard,
+			method:  http.MethodDelete,
+			headers: "Authorization",
+			status:  http.StatusOK,
+			expect: map[string]string{
+				"Access-Control-Allow-Origin":      dashboard,
+				"Access-Control-Allow-Methods":     http.MethodDelete,
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "60",
+			},
+		},
+		{
+			name:   "origin not listed",
+			cors:   func(cors *config.Cors) { cors.Origins = config.List{dashboard} },
+			origin: "http://localhost:4000",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			expect: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "method not allowed",
+			cors:   func(cors *config.Cors) { cors.Methods = config.List{http.MethodGet, http.MethodPost} },
+			origin: dashboard,
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:    "header not allowed",
+			origin:  dashboard,
+			method:  http.MethodGet,
+			headers: "X-Api-Key",
+			status:  http.StatusForbidden,
+		},
+		{
+			name:   "no origins",
+			cors:   func(cors *config.Cors) { cors.Origins = nil },
+			origin: dashboard,
+			method: http.MethodGet,
+			// answered by the router, which has no OPTIONS routes
+			status: http.StatusMethodNotAllowed,
+			expect: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cors := config.Default().Cors
+			if test.cors != nil {
+				test.cors(cors)
+			}
+			server := httptest.NewServer(r.WithCors(cors))
+			defer server.Close()
+
+			// preflight requests of browsers have no token
+			req, err := http.NewRequest(http.MethodOptions, server.URL+"/categories", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Origin", test.origin)
+			req.Header.Set("Access-Control-Request-Method", test.method)
+			if test.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", test.headers)
+			}
+			res := do(t, req, test.status, nil)
+
+			for header, expected := range test.expect {
+				if got := res.Header.Get(header); got != expected {
+					t.Errorf("unexpected %s: expected=%q, got=%q", header, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_WithCorsResponse(t *testing.T) {
+	r, _, tokensService := newTestRouter(t)
+
+	token, err := tokensService.CreateToken(context.Background(), types.DefaultUserID, &types.Token{Name: "dashboard", Scope: types.ScopeWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cors := config.Default().Cors
+	cors.Origins = config.List{"http://localhost:3000", "http://127.0.0.1:3000"}
+	server := httptest.NewServer(r.WithCors(cors))
+	t.Cleanup(server.Close)
+
+	req := newRequest(t, server, token.Secret, http.MethodPost, "/categories", &types.Category{Name: "design"})
+	req.Header.Set("Origin", "http://127.0.0.1:3000")
+	res := do(t, req, http.StatusCreated, nil)
+
+	expect := map[string]string{
+		"Access-Control-Allow-Origin":   "http://127.0.0.1:3000",
+		"Access-Control-Expose-Headers": "Location,Entity,Link",
+		"Vary":                          "Origin",
+	}
+	for header, expected := range expect {
+		if got := res.Header.Get(header); got != expected {
+			t.Errorf("unexpected %s: expected=%q, got=%q", header, expected, got)
+		}
+	}
+}
+
+// newTestRouter creates a router of a SQLite database in a temporary
+// directory, which requires API tokens except on the health probes.
+func newTestRouter(t *testing.T) (*Router, service.UsersService, service.TokensService) {
+	t.Helper()
+
+	var (
+		storage       = db.NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+		conn          = db.Lite(storage, db.Migrations(db.SQLite))
+		usersService  = service.NewUsersService(repository.NewUsersRepository(conn, db.SQLite))
+		tokensService = service.NewTokensService(repository.NewTokensRepository(conn, db.SQLite))
+		auth          = &config.Auth{Enabled: true, Exempt: config.List{"/metrics", "/healthz", "/readyz"}}
+	)
+	t.Cleanup(func() { ioext.Close(conn) })
+
+	r := New(auth, usersService, tokensService,
+		handlers.NewHealthHandler(conn, time.Second),
+		handlers.NewUsersHandler(usersService),
+		handlers.NewTokensHandler(tokensService),
+		handlers.NewCategoriesHandler(service.NewCategoriesService(repository.NewCategoriesRepository(conn, db.SQLite))),
+	)
+	return r, usersService, tokensService
+}
+
+func send(t *testing.T, server *httptest.Server, token, method, path string, input any, status int, output any) *http.Response {
+	t.Helper()
+
+	return do(t, newRequest(t, server, token, method, path, input), status, output)
+}
+
+// newRequest creates a request with the bearer token, unless it is empty.
+func newRequest(t *testing.T, server *httptest.Server, token, method, path string, input any) *http.Request {
+	t.Helper()
+
+	var body io.Reader
+	if input != nil {
+		payload, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, server.URL+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(httpext.HeaderContentType, httpext.MimeJSON)
+	if token != "" {
+		req.Header.Set(httpext.HeaderAuthorization, "Bearer "+token)
+	}
+	return req
+}
+
+func do(t *testing.T, req *http.Request, status int, output any) *http.Response {
+	t.Helper()
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ioext.Close(res.Body)
+
+	if res.StatusCode != status {
+		payload, _ := io.ReadAll(res.Body)
+		t.Fatalf("unexpected status of %s %s: expected=%d, got=%d, body=%s", req.Method, req.URL.Path, status, res.StatusCode, payload)
+	}
+	if output != nil {
+		if err = json.NewDecoder(res.Body).Decode(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res
+}
@@ -3,119 +3,338 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/ungame/timetrack/app/models"
 	"github.com/ungame/timetrack/app/observer"
 	"github.com/ungame/timetrack/app/repository"
 	"github.com/ungame/timetrack/queries"
+	"github.com/ungame/timetrack/timeext"
 	"github.com/ungame/timetrack/types"
+	"io"
 	"log"
 	"strings"
 	"time"
 )
 
+var (
+	ErrInvalidActivity  = NewError(KindValidation, "invalid_activity", "invalid activity")
+	ErrActivityOverlap  = NewError(KindConflict, "activity_overlap", "activity overlaps an existing activity")
+	ErrActivityNotFound = NewError(KindNotFound, "activity_not_found", "activity not found")
+	ErrInvalidPage      = NewError(KindBadRequest, "invalid_page", "invalid page")
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
 type ActivitiesService interface {
-	StartActivity(ctx context.Context, activity *types.Activity) (*types.Activity, error)
-	GetActivity(ctx context.Context, id int64) (*types.Activity, error)
-	GetActivities(ctx context.Context) ([]*types.Activity, error)
-	FilterActivitiesByPeriod(ctx context.Context, filter *types.PeriodFilter) ([]*types.Activity, error)
-	FinishActivity(ctx context.Context, id int64) (*types.Activity, error)
-	UpdateActivity(ctx context.Context, activity *types.Activity) (*types.Activity, error)
-	DeleteActivity(ctx context.Context, id int64) error
+	StartActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error)
+	AddActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error)
+	GetActivity(ctx context.Context, userID, id int64) (*types.Activity, error)
+	GetActivities(ctx context.Context, userID int64, filter *types.ActivityFilter, page *types.Page) ([]*types.Activity, *types.Page, error)
+	FilterActivitiesByPeriod(ctx context.Context, userID int64, filter *types.PeriodFilter) ([]*types.Activity, error)
+	ExportActivities(ctx context.Context, userID int64, filter *types.PeriodFilter) ([]*types.ActivityRecord, *time.Location, error)
+	ImportActivities(ctx context.Context, userID int64, format string, body io.Reader, timezone string, dryRun bool) (*types.ImportResult, error)
+	FinishActivity(ctx context.Context, userID, id int64) (*types.Activity, error)
+	PauseActivity(ctx context.Context, userID, id int64) (*types.Activity, error)
+	ResumeActivity(ctx context.Context, userID, id int64) (*types.Activity, error)
+	UpdateActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error)
+	GetActivityChanges(ctx context.Context, userID, id int64) ([]*types.ActivityChange, error)
+	DeleteActivity(ctx context.Context, userID, id int64) error
 }
 
 type activitiesService struct {
 	categoriesService    CategoriesService
+	projectsService      ProjectsService
 	activitiesRepository repository.ActivitiesRepository
 	obs                  observer.Observer
+	location             *time.Location
+	clock                timeext.Clock
 }
 
+// NewActivitiesService creates the activities service. Periods are resolved
+// in location unless the filter sets a timezone, and every timestamp the
+// service sets is read from clock.
 func NewActivitiesService(
 	categoriesService CategoriesService,
+	projectsService ProjectsService,
 	activitiesRepository repository.ActivitiesRepository,
 	obs observer.Observer,
+	location *time.Location,
+	clock timeext.Clock,
 ) ActivitiesService {
 
 	return &activitiesService{
 		categoriesService:    categoriesService,
+		projectsService:      projectsService,
 		activitiesRepository: activitiesRepository,
 		obs:                  obs,
+		location:             location,
+		clock:                clock,
 	}
 }
 
-func (s *activitiesService) StartActivity(ctx context.Context, activity *types.Activity) (*types.Activity, error) {
-	items, err := s.activitiesRepository.GetByStatus(ctx, models.Started)
-	if err != nil && err != sql.ErrNoRows {
+// StartActivity starts a new activity of the user, finishing the running one
+// of the user at the same time.
+func (s *activitiesService) StartActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error) {
+	category, err := s.categoryOf(ctx, activity.CategoryID)
+	if err != nil {
 		return nil, err
 	}
 
-	go func(activities []*models.Activity) {
-		for _, item := range activities {
-			_, err = s.FinishActivity(context.Background(), item.ID)
-			if err != nil {
-				log.Printf("unable to finish current activity: ID=%d, Error=%s\n", item.ID, err.Error())
-			}
-		}
-	}(items)
+	tags, err := normalizeTags(activity.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := s.projectOf(ctx, activity.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	hourlyRate, err := hourlyRateOf(activity.HourlyRate)
+	if err != nil {
+		return nil, err
+	}
 
 	newActivity := &models.Activity{
+		UserID:      userID,
 		CategoryID:  activity.CategoryID,
+		ProjectID:   projectID,
+		Billable:    activity.Billable != nil && *activity.Billable,
+		HourlyRate:  hourlyRate,
 		Description: activity.Description,
 		Status:      models.Started,
+		Tags:        tags,
 	}
 
-	newActivity.SetStartedAt(time.Now())
-	newActivity.SetUpdatedAt(time.Now())
+	now := s.clock.Now()
+	newActivity.SetStartedAt(now)
+	newActivity.SetUpdatedAt(now)
 
-	newActivity, err = s.activitiesRepository.Create(ctx, newActivity)
+	newActivity, finished, err := s.activitiesRepository.Start(ctx, newActivity)
 	if err != nil {
 		return nil, err
 	}
 
+	s.observeFinished(ctx, finished)
+
 	log.Printf("activity started: ID=%d\n", newActivity.ID)
 
-	category, err := s.categoriesService.GetCategory(ctx, newActivity.CategoryID)
-	if err == nil {
-		s.obs.Count("started", category.Name)
-	} else {
-		log.Println("unable to get category:", err.Error())
-	}
+	s.obs.Count("started", category.Name)
+
+	return newActivity.Type(now), nil
+}
+
+// observeFinished reports the activities finished because another one started.
+func (s *activitiesService) observeFinished(ctx context.Context, activities []*models.Activity) {
+	for _, activity := range activities {
+		log.Printf("activity finished: ID=%d\n", activity.ID)
 
-	return newActivity.Type(), nil
+		category, err := s.categoriesService.GetCategory(ctx, activity.CategoryID)
+		if err != nil {
+			log.Println("unable to get category:", err.Error())
+			continue
+		}
+		s.obs.Count("finished", category.Name)
+		s.obs.DurationOf("finished", category.Name, activity.Duration(activity.FinishedAt.Time))
+	}
 }
 
-func (s *activitiesService) GetActivity(ctx context.Context, id int64) (*types.Activity, error) {
-	activity, err := s.activitiesRepository.Get(ctx, id)
+// AddActivity records an already finished activity with the started_at and
+// finished_at given by the caller, unless it overlaps another finished
+// activity of the user.
+func (s *activitiesService) AddActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error) {
+	newActivity, category, err := s.newFinishedActivity(ctx, userID, activity)
 	if err != nil {
 		return nil, err
 	}
-	return activity.Type(), nil
-}
 
-func (s *activitiesService) GetActivities(ctx context.Context) ([]*types.Activity, error) {
-	items, err := s.activitiesRepository.GetAll(ctx)
+	overlapping, err := s.activitiesRepository.Add(ctx, newActivity)
 	if err != nil {
 		return nil, err
 	}
+	if len(overlapping) > 0 {
+		return nil, overlapError(overlapping)
+	}
+
+	log.Printf("activity added: ID=%d\n", newActivity.ID)
+
+	s.obs.Count("added", category.Name)
+
+	return newActivity.Type(s.clock.Now()), nil
+}
+
+// newFinishedActivity validates an activity of the user recorded after the
+// fact and returns it along with its category.
+func (s *activitiesService) newFinishedActivity(ctx context.Context, userID int64, activity *types.Activity) (*models.Activity, *types.Category, error) {
+	if activity.StartedAt == nil {
+		return nil, nil, invalidField(ErrInvalidActivity, "started_at", "is required")
+	}
+	if activity.FinishedAt == nil {
+		return nil, nil, invalidField(ErrInvalidActivity, "finished_at", "is required")
+	}
+
+	var (
+		startedAt  = *activity.StartedAt
+		finishedAt = *activity.FinishedAt
+	)
+
+	if !finishedAt.After(startedAt) {
+		return nil, nil, invalidField(ErrInvalidActivity, "finished_at", "must be after started_at")
+	}
+
+	if finishedAt.After(s.clock.Now()) {
+		return nil, nil, invalidField(ErrInvalidActivity, "finished_at", "must not be in the future")
+	}
+
+	category, err := s.categoryOf(ctx, activity.CategoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags, err := normalizeTags(activity.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectID, err := s.projectOf(ctx, activity.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hourlyRate, err := hourlyRateOf(activity.HourlyRate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newActivity := &models.Activity{
+		UserID:      userID,
+		CategoryID:  activity.CategoryID,
+		ProjectID:   projectID,
+		Billable:    activity.Billable != nil && *activity.Billable,
+		HourlyRate:  hourlyRate,
+		Description: activity.Description,
+		Status:      models.Finished,
+		Tags:        tags,
+	}
+
+	newActivity.SetStartedAt(startedAt)
+	newActivity.SetUpdatedAt(s.clock.Now())
+	newActivity.SetFinishedAt(finishedAt)
+
+	return newActivity, category, nil
+}
+
+// categoryOf returns the category of an activity of the category id, which
+// must exist.
+func (s *activitiesService) categoryOf(ctx context.Context, id int64) (*types.Category, error) {
+	category, err := s.categoriesService.GetCategory(ctx, id)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return nil, invalidField(ErrInvalidActivity, "category_id", "must be an existing category, %d is not", id)
+	}
+	return category, err
+}
+
+// projectOf returns the project column of an activity of the project id,
+// which is null when id is nil or zero and must exist otherwise.
+func (s *activitiesService) projectOf(ctx context.Context, id *int64) (sql.NullInt64, error) {
+	if id == nil || *id == 0 {
+		return sql.NullInt64{}, nil
+	}
+	_, err := s.projectsService.GetProject(ctx, *id)
+	if errors.Is(err, ErrProjectNotFound) {
+		return sql.NullInt64{}, invalidField(ErrInvalidActivity, "project_id", "must be an existing project, %d is not", *id)
+	}
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: *id, Valid: true}, nil
+}
+
+// hourlyRateOf returns the hourly rate column of an activity of rate, which
+// is null unless the rate of the category is overridden.
+func hourlyRateOf(rate *int64) (sql.NullInt64, error) {
+	if rate == nil {
+		return sql.NullInt64{}, nil
+	}
+	if *rate < 0 {
+		return sql.NullInt64{}, invalidField(ErrInvalidActivity, "hourly_rate", "must not be negative")
+	}
+	return sql.NullInt64{Int64: *rate, Valid: true}, nil
+}
+
+func overlapError(overlapping []*models.Activity) error {
+	ids := make([]string, 0, len(overlapping))
+	for _, item := range overlapping {
+		ids = append(ids, fmt.Sprint(item.ID))
+	}
+	return fmt.Errorf("%w: ID=%s", ErrActivityOverlap, strings.Join(ids, ","))
+}
+
+func (s *activitiesService) GetActivity(ctx context.Context, userID, id int64) (*types.Activity, error) {
+	activity, err := s.activitiesRepository.Get(ctx, userID, id)
+	if err != nil {
+		return nil, notFound(err, ErrActivityNotFound, id)
+	}
+	return activity.Type(s.clock.Now()), nil
+}
+
+// GetActivities returns a page of the activities ordered by id, only of the
+// ones of the filter if it is set, and the next page, which is nil after the
+// last one. Pages have defaultPageSize activities unless they set a size.
+func (s *activitiesService) GetActivities(ctx context.Context, userID int64, filter *types.ActivityFilter, page *types.Page) ([]*types.Activity, *types.Page, error) {
+	activityFilter, err := activityFilterOf(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := defaultPageSize
+	if page.Size != 0 {
+		size = page.Size
+	}
+	if size < 0 || size > maxPageSize {
+		return nil, nil, invalidField(ErrInvalidPage, "page_size", "must be between 1 and %d", maxPageSize)
+	}
+	if page.AfterID < 0 {
+		return nil, nil, invalidField(ErrInvalidPage, "after_id", "must not be negative")
+	}
+
+	// one more activity tells whether there is a next page
+	items, err := s.activitiesRepository.GetAll(ctx, userID, activityFilter, page.AfterID, size+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *types.Page
+	if len(items) > size {
+		items = items[:size]
+		next = &types.Page{AfterID: items[size-1].ID, Size: size}
+	}
+
+	now := s.clock.Now()
 	activities := make([]*types.Activity, 0, len(items))
 	for _, item := range items {
-		activities = append(activities, item.Type())
+		activities = append(activities, item.Type(now))
 	}
-	return activities, nil
+	return activities, next, nil
 }
 
-func (s *activitiesService) FinishActivity(ctx context.Context, id int64) (*types.Activity, error) {
-	activity, err := s.activitiesRepository.Get(ctx, id)
+func (s *activitiesService) FinishActivity(ctx context.Context, userID, id int64) (*types.Activity, error) {
+	activity, err := s.activitiesRepository.Get(ctx, userID, id)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrActivityNotFound, id)
 	}
 	if activity.Status == models.Finished {
-		return activity.Type(), nil
+		return activity.Type(s.clock.Now()), nil
 	}
+	now := s.clock.Now()
 	activity.Status = models.Finished
-	activity.SetFinishedAt(time.Now())
-	activity.SetUpdatedAt(time.Now())
-	_, err = s.activitiesRepository.Update(ctx, activity)
+	activity.SetFinishedAt(now)
+	activity.SetUpdatedAt(now)
+	_, _, err = s.activitiesRepository.UpdateStatus(ctx, activity, now)
 	if err != nil {
 		return nil, err
 	}
@@ -125,28 +344,169 @@ func (s *activitiesService) FinishActivity(ctx context.Context, id int64) (*type
 	category, err := s.categoriesService.GetCategory(ctx, activity.CategoryID)
 	if err == nil {
 		s.obs.Count("finished", category.Name)
-		s.obs.DurationOf("finished", category.Name, activity.StartedAt.Time)
+		s.obs.DurationOf("finished", category.Name, activity.Duration(now))
 	} else {
 		log.Println("unable to get category:", err.Error())
 	}
 
-	return activity.Type(), nil
+	return activity.Type(now), nil
 }
 
-func (s *activitiesService) UpdateActivity(ctx context.Context, activity *types.Activity) (*types.Activity, error) {
-	existing, err := s.activitiesRepository.Get(ctx, activity.ID)
+// PauseActivity closes the current work interval of a started activity.
+func (s *activitiesService) PauseActivity(ctx context.Context, userID, id int64) (*types.Activity, error) {
+	activity, err := s.activitiesRepository.Get(ctx, userID, id)
+	if err != nil {
+		return nil, notFound(err, ErrActivityNotFound, id)
+	}
+	if activity.Status != models.Started {
+		return nil, invalidField(ErrInvalidActivity, "status", "must be started to pause the activity, it is %s", activity.Status)
+	}
+	now := s.clock.Now()
+	activity.Status = models.Paused
+	activity.SetUpdatedAt(now)
+	_, _, err = s.activitiesRepository.UpdateStatus(ctx, activity, now)
 	if err != nil {
 		return nil, err
 	}
-	existing.CategoryID = activity.CategoryID
-	existing.Description = activity.Description
-	existing.SetUpdatedAt(time.Now())
-	_, err = s.activitiesRepository.Update(ctx, existing)
+
+	log.Printf("activity paused: ID=%d\n", id)
+
+	category, err := s.categoriesService.GetCategory(ctx, activity.CategoryID)
+	if err == nil {
+		s.obs.Count("paused", category.Name)
+	} else {
+		log.Println("unable to get category:", err.Error())
+	}
+
+	return activity.Type(now), nil
+}
+
+// ResumeActivity opens a new work interval for a paused activity, finishing
+// the running one as StartActivity does.
+func (s *activitiesService) ResumeActivity(ctx context.Context, userID, id int64) (*types.Activity, error) {
+	activity, err := s.activitiesRepository.Get(ctx, userID, id)
+	if err != nil {
+		return nil, notFound(err, ErrActivityNotFound, id)
+	}
+	if activity.Status != models.Paused {
+		return nil, invalidField(ErrInvalidActivity, "status", "must be paused to resume the activity, it is %s", activity.Status)
+	}
+
+	now := s.clock.Now()
+	activity.Status = models.Started
+	activity.SetUpdatedAt(now)
+	_, finished, err := s.activitiesRepository.UpdateStatus(ctx, activity, now)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("activity updated: ID=%d\n", existing.ID)
+	s.observeFinished(ctx, finished)
+
+	log.Printf("activity resumed: ID=%d\n", id)
+
+	category, err := s.categoriesService.GetCategory(ctx, activity.CategoryID)
+	if err == nil {
+		s.obs.Count("resumed", category.Name)
+	} else {
+		log.Println("unable to get category:", err.Error())
+	}
+
+	return activity.Type(now), nil
+}
+
+// UpdateActivity replaces the description of the existing activity,
+// clearing it when empty, and applies the other non-zero fields of
+// activity, replacing its tags when they are set, even if empty, and
+// removing it from its project when the project is set to 0. Timestamp
+// corrections are validated against the activity status and its
+// neighbours, and every changed field is recorded in the activity history.
+func (s *activitiesService) UpdateActivity(ctx context.Context, userID int64, activity *types.Activity) (*types.Activity, error) {
+	existing, err := s.activitiesRepository.Get(ctx, userID, activity.ID)
+	if err != nil {
+		return nil, notFound(err, ErrActivityNotFound, activity.ID)
+	}
+
+	var (
+		now     = s.clock.Now()
+		changes = make([]*models.ActivityChange, 0, 4)
+	)
+
+	if activity.CategoryID != 0 && activity.CategoryID != existing.CategoryID {
+		if _, err = s.categoryOf(ctx, activity.CategoryID); err != nil {
+			return nil, err
+		}
+		changes = append(changes, models.NewActivityChange("category_id", formatInt(existing.CategoryID), formatInt(activity.CategoryID), now))
+		existing.CategoryID = activity.CategoryID
+	}
+
+	if activity.ProjectID != nil {
+		projectID, err := s.projectOf(ctx, activity.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID != existing.ProjectID {
+			changes = append(changes, models.NewActivityChange("project_id", formatNullInt(existing.ProjectID), formatNullInt(projectID), now))
+			existing.ProjectID = projectID
+		}
+	}
+
+	if activity.Billable != nil && *activity.Billable != existing.Billable {
+		changes = append(changes, models.NewActivityChange("billable", formatBool(existing.Billable), formatBool(*activity.Billable), now))
+		existing.Billable = *activity.Billable
+	}
+
+	if activity.HourlyRate != nil && (!existing.HourlyRate.Valid || *activity.HourlyRate != existing.HourlyRate.Int64) {
+		hourlyRate, err := hourlyRateOf(activity.HourlyRate)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, models.NewActivityChange("hourly_rate", formatNullInt(existing.HourlyRate), formatNullInt(hourlyRate), now))
+		existing.HourlyRate = hourlyRate
+	}
+
+	if activity.Description != existing.Description {
+		changes = append(changes, models.NewActivityChange("description", formatString(existing.Description), formatString(activity.Description), now))
+		existing.Description = activity.Description
+	}
+
+	if activity.Tags != nil {
+		tags, err := normalizeTags(activity.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if !equalTags(tags, existing.Tags) {
+			changes = append(changes, models.NewActivityChange("tags", formatTags(existing.Tags), formatTags(tags), now))
+			existing.Tags = tags
+		}
+	}
+
+	timestamps, err := s.correctTimestamps(ctx, userID, existing, activity, now)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, timestamps...)
+
+	existing.SetUpdatedAt(now)
+	if len(timestamps) == 0 {
+		_, err = s.activitiesRepository.Update(ctx, existing, changes...)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		end := now
+		if existing.Status == models.Finished {
+			end = existing.FinishedAt.Time
+		}
+		overlapping, err := s.activitiesRepository.Correct(ctx, existing, end, changes...)
+		if err != nil {
+			return nil, err
+		}
+		if len(overlapping) > 0 {
+			return nil, overlapError(overlapping)
+		}
+	}
+
+	log.Printf("activity updated: ID=%d, Changes=%d\n", existing.ID, len(changes))
 
 	category, err := s.categoriesService.GetCategory(ctx, existing.CategoryID)
 	if err == nil {
@@ -155,35 +515,228 @@ func (s *activitiesService) UpdateActivity(ctx context.Context, activity *types.
 		log.Println("unable to get category:", err.Error())
 	}
 
-	return existing.Type(), nil
+	return existing.Type(now), nil
+}
+
+// correctTimestamps applies the started_at and finished_at of activity to the
+// existing one and returns their changes. Overlaps with finished activities
+// are checked when the changes are saved, with Correct.
+func (s *activitiesService) correctTimestamps(ctx context.Context, userID int64, existing *models.Activity, activity *types.Activity, now time.Time) ([]*models.ActivityChange, error) {
+	var (
+		startedAt  = existing.StartedAt.Time
+		finishedAt = existing.FinishedAt.Time
+		changes    = make([]*models.ActivityChange, 0, 2)
+	)
+
+	if activity.StartedAt != nil && !activity.StartedAt.Equal(startedAt) {
+		startedAt = *activity.StartedAt
+	}
+
+	if activity.FinishedAt != nil && !activity.FinishedAt.Equal(finishedAt) {
+		if existing.Status != models.Finished {
+			return nil, invalidField(ErrInvalidActivity, "finished_at", "can only be changed on finished activities")
+		}
+		finishedAt = *activity.FinishedAt
+	}
+
+	if startedAt.Equal(existing.StartedAt.Time) && finishedAt.Equal(existing.FinishedAt.Time) {
+		return changes, nil
+	}
+
+	if startedAt.After(now) {
+		return nil, invalidField(ErrInvalidActivity, "started_at", "must not be in the future")
+	}
+
+	if existing.Status == models.Finished {
+		if finishedAt.After(now) {
+			return nil, invalidField(ErrInvalidActivity, "finished_at", "must not be in the future")
+		}
+		if !finishedAt.After(startedAt) {
+			return nil, invalidField(ErrInvalidActivity, "finished_at", "must be after started_at")
+		}
+
+		running, err := s.activitiesRepository.GetByStatus(ctx, userID, models.Started)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range running {
+			if item.ID != existing.ID && item.StartedAt.Time.Before(finishedAt) {
+				return nil, fmt.Errorf("%w: ID=%d", ErrActivityOverlap, item.ID)
+			}
+		}
+	}
+
+	var first, last *models.ActivityInterval
+	if len(existing.Intervals) > 0 {
+		first = existing.Intervals[0]
+		last = existing.Intervals[len(existing.Intervals)-1]
+	}
+
+	if !startedAt.Equal(existing.StartedAt.Time) {
+		if first != nil && first.FinishedAt.Valid && !first.FinishedAt.Time.After(startedAt) {
+			return nil, invalidField(ErrInvalidActivity, "started_at", "must be before the end of the first work interval")
+		}
+		changes = append(changes, models.NewActivityChange("started_at", formatTime(existing.StartedAt), formatTime(sql.NullTime{Time: startedAt, Valid: true}), now))
+		existing.SetStartedAt(startedAt)
+		if first != nil {
+			first.SetStartedAt(startedAt)
+		}
+	}
+
+	if !finishedAt.Equal(existing.FinishedAt.Time) {
+		if last != nil && !finishedAt.After(last.StartedAt.Time) {
+			return nil, invalidField(ErrInvalidActivity, "finished_at", "must be after the start of the last work interval")
+		}
+		changes = append(changes, models.NewActivityChange("finished_at", formatTime(existing.FinishedAt), formatTime(sql.NullTime{Time: finishedAt, Valid: true}), now))
+		existing.SetFinishedAt(finishedAt)
+		if last != nil {
+			last.SetFinishedAt(finishedAt)
+		}
+	}
+
+	return changes, nil
 }
 
-func (s *activitiesService) FilterActivitiesByPeriod(ctx context.Context, filter *types.PeriodFilter) ([]*types.Activity, error) {
-	period := queries.PeriodType(strings.TrimSpace(strings.ToLower(filter.PeriodName)))
-	if !period.IsValid() {
-		return nil, fmt.Errorf("invalid period filter: %s", filter.PeriodName)
+func (s *activitiesService) GetActivityChanges(ctx context.Context, userID, id int64) ([]*types.ActivityChange, error) {
+	if _, err := s.activitiesRepository.Get(ctx, userID, id); err != nil {
+		return nil, notFound(err, ErrActivityNotFound, id)
+	}
+	items, err := s.activitiesRepository.GetChanges(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]*types.ActivityChange, 0, len(items))
+	for _, item := range items {
+		changes = append(changes, item.Type())
+	}
+	return changes, nil
+}
+
+func (s *activitiesService) FilterActivitiesByPeriod(ctx context.Context, userID int64, filter *types.PeriodFilter) ([]*types.Activity, error) {
+	period, err := periodOf(filter)
+	if err != nil {
+		return nil, err
+	}
+	location, err := locationOf(filter.Timezone, s.location)
+	if err != nil {
+		return nil, err
 	}
 	order := queries.Order(strings.TrimSpace(strings.ToLower(filter.OrderBy)))
 	if !order.IsValid() {
-		return nil, fmt.Errorf("invalid order filter: %s", filter.OrderBy)
+		return nil, invalidField(ErrInvalidFilter, "order", "must be asc or desc, not %q", filter.OrderBy)
+	}
+	activityFilter, err := activityFilterOf(&filter.ActivityFilter)
+	if err != nil {
+		return nil, err
 	}
-	items, err := s.activitiesRepository.FilterByPeriod(ctx, period, order, filter.Limit)
+	start, end := period.Range(s.clock, location)
+	items, err := s.activitiesRepository.FilterByPeriod(ctx, userID, start, end, activityFilter, order, filter.Limit)
 	if err != nil {
 		return nil, err
 	}
+	now := s.clock.Now()
 	activities := make([]*types.Activity, 0, len(items))
 	for _, item := range items {
-		activities = append(activities, item.Type())
+		activities = append(activities, item.Type(now))
 	}
 	return activities, nil
 }
 
-func (s *activitiesService) DeleteActivity(ctx context.Context, id int64) error {
-	existing, err := s.activitiesRepository.Get(ctx, id)
+// ExportActivities returns the activities of the filter with their category
+// names and timestamps in the timezone of the filter, which is also returned.
+func (s *activitiesService) ExportActivities(ctx context.Context, userID int64, filter *types.PeriodFilter) ([]*types.ActivityRecord, *time.Location, error) {
+	location, err := locationOf(filter.Timezone, s.location)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	activities, err := s.FilterActivitiesByPeriod(ctx, userID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := make([]*types.ActivityRecord, 0, len(activities))
+	for _, activity := range activities {
+		record := &types.ActivityRecord{Activity: *activity}
+		category, err := s.categoriesService.GetCategory(ctx, activity.CategoryID)
+		if err == nil {
+			record.Category = category.Name
+		} else {
+			log.Println("unable to get category:", err.Error())
+		}
+		for _, t := range []*time.Time{record.StartedAt, record.UpdatedAt, record.FinishedAt} {
+			if t != nil {
+				*t = t.In(location)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, location, nil
+}
+
+// activityFilterOf returns the repository filter of the activities with the
+// normalized tag, project and client of the filter.
+func activityFilterOf(filter *types.ActivityFilter) (*repository.ActivityFilter, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	activityFilter := &repository.ActivityFilter{
+		ProjectID: filter.ProjectID,
+		ClientID:  filter.ClientID,
 	}
-	rows, err := s.activitiesRepository.Delete(ctx, existing.ID)
+	if tag := strings.TrimSpace(filter.Tag); tag != "" {
+		name, ok := types.NormalizeTag(tag)
+		if !ok {
+			return nil, invalidField(ErrInvalidFilter, "tag", "must have up to %d letters, digits, - or _, not %q", types.MaxTagLength, filter.Tag)
+		}
+		activityFilter.Tag = name
+	}
+	return activityFilter, nil
+}
+
+// periodOf returns the date range of the filter when from or to is set and
+// its named period otherwise, with weeks starting on the filter week start.
+func periodOf(filter *types.PeriodFilter) (queries.Period, error) {
+	from, to := strings.TrimSpace(filter.From), strings.TrimSpace(filter.To)
+	if from == "" && to == "" {
+		period := queries.PeriodType(strings.TrimSpace(strings.ToLower(filter.PeriodName)))
+		if !period.IsValid() {
+			return nil, invalidField(ErrInvalidFilter, "period", "%q is not a period", filter.PeriodName)
+		}
+		if filter.WeekStart == "" {
+			return period, nil
+		}
+		weekStart, err := timeext.ParseWeekday(filter.WeekStart)
+		if err != nil {
+			return nil, invalidField(ErrInvalidFilter, "week_start", "must be a day of the week, not %q", filter.WeekStart)
+		}
+		return queries.WeekPeriod{PeriodType: period, WeekStart: weekStart}, nil
+	}
+	if filter.PeriodName != "" {
+		return nil, invalidField(ErrInvalidFilter, "period", "can't be combined with from and to")
+	}
+	dateRange, err := queries.NewDateRange(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err.Error())
+	}
+	return dateRange, nil
+}
+
+// locationOf returns the location of the timezone of a request, or fallback
+// if it is empty.
+func locationOf(timezone string, fallback *time.Location) (*time.Location, error) {
+	location, err := timeext.LoadLocation(strings.TrimSpace(timezone), fallback)
+	if err != nil {
+		return nil, invalidField(ErrInvalidFilter, "tz", "must be an IANA timezone, such as America/Sao_Paulo, not %q", timezone)
+	}
+	return location, nil
+}
+
+func (s *activitiesService) DeleteActivity(ctx context.Context, userID, id int64) error {
+	existing, err := s.activitiesRepository.Get(ctx, userID, id)
+	if err != nil {
+		return notFound(err, ErrActivityNotFound, id)
+	}
+	rows, err := s.activitiesRepository.Delete(ctx, userID, existing.ID)
 	if err != nil {
 		return err
 	}
@@ -197,3 +750,29 @@ func (s *activitiesService) DeleteActivity(ctx context.Context, id int64) error
 	}
 	return nil
 }
+
+func formatInt(value int64) sql.NullString {
+	return sql.NullString{String: fmt.Sprint(value), Valid: true}
+}
+
+func formatNullInt(value sql.NullInt64) sql.NullString {
+	if !value.Valid {
+		return sql.NullString{}
+	}
+	return formatInt(value.Int64)
+}
+
+func formatBool(value bool) sql.NullString {
+	return sql.NullString{String: fmt.Sprint(value), Valid: true}
+}
+
+func formatString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
+
+func formatTime(value sql.NullTime) sql.NullString {
+	if !value.Valid {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: value.Time.UTC().Format(time.RFC3339Nano), Valid: true}
+}
@@ -2,16 +2,33 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/ungame/timetrack/app/models"
 	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/types"
 	"log"
+	"strings"
 	"sync"
+	"time"
+)
+
+const maxCategoryNameLength = 50
+
+var (
+	ErrInvalidCategory  = NewError(KindValidation, "invalid_category", "invalid category")
+	ErrCategoryExists   = NewError(KindConflict, "category_exists", "category already exists")
+	ErrCategoryInUse    = NewError(KindConflict, "category_in_use", "category is referenced by activities")
+	ErrCategoryNotFound = NewError(KindNotFound, "category_not_found", "category not found")
 )
 
 type CategoriesService interface {
+	CreateCategory(ctx context.Context, category *types.Category) (*types.Category, error)
 	GetCategory(ctx context.Context, id int64) (*types.Category, error)
 	GetCategories(ctx context.Context) ([]*types.Category, error)
+	UpdateCategory(ctx context.Context, category *types.Category) (*types.Category, error)
+	DeleteCategory(ctx context.Context, id, replacementID int64) error
 }
 
 type categoriesService struct {
@@ -40,16 +57,50 @@ func (s *categoriesService) load() {
 	}
 }
 
+func (s *categoriesService) CreateCategory(ctx context.Context, category *types.Category) (*types.Category, error) {
+	name, err := s.validate(category)
+	if err != nil {
+		return nil, err
+	}
+
+	newCategory := &models.Category{
+		Name:        name,
+		Description: strings.TrimSpace(category.Description),
+		HourlyRate:  category.HourlyRate,
+	}
+
+	now := time.Now()
+	newCategory.SetCreatedAt(now)
+	newCategory.SetUpdatedAt(now)
+
+	newCategory, err = s.categoriesRepository.Create(ctx, newCategory)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[newCategory.ID] = newCategory
+	s.mutex.Unlock()
+
+	log.Printf("category created: ID=%d\n", newCategory.ID)
+
+	return newCategory.Type(), nil
+}
+
 func (s *categoriesService) GetCategory(ctx context.Context, id int64) (*types.Category, error) {
 	s.mutex.RLock()
-	if category, ok := s.cache[id]; ok {
-		s.mutex.RUnlock()
+	category, ok := s.cache[id]
+	s.mutex.RUnlock()
+	if ok {
 		return category.Type(), nil
 	}
 
 	category, err := s.categoriesRepository.Get(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrCategoryNotFound, id)
 	}
 
 	s.mutex.Lock()
@@ -70,3 +121,103 @@ func (s *categoriesService) GetCategories(ctx context.Context) ([]*types.Categor
 	}
 	return categories, nil
 }
+
+func (s *categoriesService) UpdateCategory(ctx context.Context, category *types.Category) (*types.Category, error) {
+	existing, err := s.categoriesRepository.Get(ctx, category.ID)
+	if err != nil {
+		return nil, notFound(err, ErrCategoryNotFound, category.ID)
+	}
+
+	name, err := s.validate(category)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.Name = name
+	existing.Description = strings.TrimSpace(category.Description)
+	existing.HourlyRate = category.HourlyRate
+	existing.SetUpdatedAt(time.Now())
+
+	_, err = s.categoriesRepository.Update(ctx, existing)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[existing.ID] = existing
+	s.mutex.Unlock()
+
+	log.Printf("category updated: ID=%d\n", existing.ID)
+
+	return existing.Type(), nil
+}
+
+// DeleteCategory removes the category id. When the category still has
+// activities, they are moved to replacementID, or the deletion is refused
+// with ErrCategoryInUse if no replacement is given.
+func (s *categoriesService) DeleteCategory(ctx context.Context, id, replacementID int64) error {
+	existing, err := s.categoriesRepository.Get(ctx, id)
+	if err != nil {
+		return notFound(err, ErrCategoryNotFound, id)
+	}
+
+	var rows, count int64
+
+	if replacementID > 0 {
+		if replacementID == existing.ID {
+			return invalidField(ErrInvalidCategory, "reassign_to", "must be another category")
+		}
+		_, err = s.GetCategory(ctx, replacementID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			return invalidField(ErrInvalidCategory, "reassign_to", "must be an existing category, %d is not", replacementID)
+		}
+		if err != nil {
+			return err
+		}
+		rows, err = s.categoriesRepository.Replace(ctx, existing.ID, replacementID)
+	} else {
+		rows, count, err = s.categoriesRepository.Delete(ctx, existing.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d activities", ErrCategoryInUse, count)
+	}
+
+	s.mutex.Lock()
+	delete(s.cache, existing.ID)
+	s.mutex.Unlock()
+
+	if rows > 0 {
+		log.Printf("category deleted: ID=%d\n", existing.ID)
+	}
+
+	return nil
+}
+
+func (s *categoriesService) validate(category *types.Category) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(category.Name))
+	if name == "" {
+		return "", invalidField(ErrInvalidCategory, "name", "is required")
+	}
+	if len(name) > maxCategoryNameLength {
+		return "", invalidField(ErrInvalidCategory, "name", "must have at most %d characters", maxCategoryNameLength)
+	}
+	if category.HourlyRate < 0 {
+		return "", invalidField(ErrInvalidCategory, "hourly_rate", "must not be negative")
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, cached := range s.cache {
+		if cached.Name == name && cached.ID != category.ID {
+			return "", fmt.Errorf("%w: %s", ErrCategoryExists, name)
+		}
+	}
+
+	return name, nil
+}
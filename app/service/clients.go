@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxClientNameLength = 100
+
+var (
+	ErrInvalidClient  = NewError(KindValidation, "invalid_client", "invalid client")
+	ErrClientExists   = NewError(KindConflict, "client_exists", "client already exists")
+	ErrClientInUse    = NewError(KindConflict, "client_in_use", "client is referenced by projects")
+	ErrClientNotFound = NewError(KindNotFound, "client_not_found", "client not found")
+)
+
+type ClientsService interface {
+	CreateClient(ctx context.Context, client *types.Client) (*types.Client, error)
+	GetClient(ctx context.Context, id int64) (*types.Client, error)
+	GetClients(ctx context.Context) ([]*types.Client, error)
+	UpdateClient(ctx context.Context, client *types.Client) (*types.Client, error)
+	DeleteClient(ctx context.Context, id int64) error
+}
+
+type clientsService struct {
+	clientsRepository repository.ClientsRepository
+	mutex             *sync.RWMutex
+	cache             map[int64]*models.Client
+}
+
+// NewClientsService creates the clients service, which caches the clients
+// as the categories and projects services do.
+func NewClientsService(clientsRepository repository.ClientsRepository) ClientsService {
+	svc := &clientsService{
+		clientsRepository: clientsRepository,
+	}
+	svc.load()
+	return svc
+}
+
+func (s *clientsService) load() {
+	clients, err := s.clientsRepository.GetAll(context.Background())
+	if err != nil {
+		log.Panicln("unable to cache clients:", err.Error())
+	}
+	s.mutex = &sync.RWMutex{}
+	s.cache = make(map[int64]*models.Client, len(clients))
+	for _, client := range clients {
+		s.cache[client.ID] = client
+	}
+}
+
+func (s *clientsService) CreateClient(ctx context.Context, client *types.Client) (*types.Client, error) {
+	name, err := s.validate(client)
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := &models.Client{
+		Name:        name,
+		Description: strings.TrimSpace(client.Description),
+	}
+
+	now := time.Now()
+	newClient.SetCreatedAt(now)
+	newClient.SetUpdatedAt(now)
+
+	newClient, err = s.clientsRepository.Create(ctx, newClient)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrClientExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[newClient.ID] = newClient
+	s.mutex.Unlock()
+
+	log.Printf("client created: ID=%d\n", newClient.ID)
+
+	return newClient.Type(), nil
+}
+
+func (s *clientsService) GetClient(ctx context.Context, id int64) (*types.Client, error) {
+	s.mutex.RLock()
+	client, ok := s.cache[id]
+	s.mutex.RUnlock()
+	if ok {
+		return client.Type(), nil
+	}
+
+	client, err := s.clientsRepository.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrClientNotFound, id)
+	}
+
+	s.mutex.Lock()
+	s.cache[client.ID] = client
+	s.mutex.Unlock()
+
+	return client.Type(), nil
+}
+
+func (s *clientsService) GetClients(ctx context.Context) ([]*types.Client, error) {
+	items, err := s.clientsRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clients := make([]*types.Client, 0, len(items))
+	for _, item := range items {
+		clients = append(clients, item.Type())
+	}
+	return clients, nil
+}
+
+func (s *clientsService) UpdateClient(ctx context.Context, client *types.Client) (*types.Client, error) {
+	existing, err := s.clientsRepository.Get(ctx, client.ID)
+	if err != nil {
+		return nil, notFound(err, ErrClientNotFound, client.ID)
+	}
+
+	name, err := s.validate(client)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.Name = name
+	existing.Description = strings.TrimSpace(client.Description)
+	existing.SetUpdatedAt(time.Now())
+
+	_, err = s.clientsRepository.Update(ctx, existing)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrClientExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[existing.ID] = existing
+	s.mutex.Unlock()
+
+	log.Printf("client updated: ID=%d\n", existing.ID)
+
+	return existing.Type(), nil
+}
+
+// DeleteClient removes the client id, which is refused with ErrClientInUse
+// while it still has projects.
+func (s *clientsService) DeleteClient(ctx context.Context, id int64) error {
+	existing, err := s.clientsRepository.Get(ctx, id)
+	if err != nil {
+		return notFound(err, ErrClientNotFound, id)
+	}
+
+	rows, count, err := s.clientsRepository.Delete(ctx, existing.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d projects", ErrClientInUse, count)
+	}
+
+	s.mutex.Lock()
+	delete(s.cache, existing.ID)
+	s.mutex.Unlock()
+
+	if rows > 0 {
+		log.Printf("client deleted: ID=%d\n", existing.ID)
+	}
+
+	return nil
+}
+
+func (s *clientsService) validate(client *types.Client) (string, error) {
+	name := strings.TrimSpace(client.Name)
+	if name == "" {
+		return "", invalidField(ErrInvalidClient, "name", "is required")
+	}
+	if len(name) > maxClientNameLength {
+		return "", invalidField(ErrInvalidClient, "name", "must have at most %d characters", maxClientNameLength)
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, cached := range s.cache {
+		if strings.EqualFold(cached.Name, name) && cached.ID != client.ID {
+			return "", fmt.Errorf("%w: %s", ErrClientExists, name)
+		}
+	}
+
+	return name, nil
+}
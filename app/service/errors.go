@@ -0,0 +1,83 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Kind is the kind of a domain error, which tells the clients whether to fix
+// the request, repeat it later or report it.
+type Kind int
+
+const (
+	// KindInternal errors are failures of the server, such as of the database.
+	KindInternal Kind = iota
+	// KindBadRequest errors are requests that can't be understood, such as
+	// a query parameter of the wrong type.
+	KindBadRequest
+	// KindValidation errors are requests breaking a rule of the domain.
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindUnsupportedMediaType
+)
+
+// Error is a domain error with a stable code, e.g. invalid_activity, which the
+// clients may rely on, unlike the messages of the errors wrapping it.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func NewError(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError is a domain error on a field of the request, e.g. finished_at,
+// whose message tells what the field must be.
+type FieldError struct {
+	Err     *Error
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Err, e.Field, e.Message)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrBadRequest    = NewError(KindBadRequest, "bad_request", "bad request")
+	ErrInvalidFilter = NewError(KindBadRequest, "invalid_filter", "invalid filter")
+)
+
+// BadRequest wraps an error reading the request, such as a JSON syntax error,
+// in ErrBadRequest.
+func BadRequest(err error) error {
+	return fmt.Errorf("%w: %s", ErrBadRequest, err.Error())
+}
+
+// invalidField returns err on the field, with a message formatted by format.
+func invalidField(err *Error, field, format string, args ...any) error {
+	return &FieldError{Err: err, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// notFound wraps sql.ErrNoRows of the repositories in errNotFound, naming the
+// id that wasn't found, and returns the other errors as they are.
+func notFound(err error, errNotFound *Error, id any) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %v", errNotFound, id)
+	}
+	return err
+}
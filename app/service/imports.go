@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/httpext"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidImport = NewError(KindValidation, "invalid_import", "invalid import")
+
+// importRecord is a row of an import file: the activity it holds, or the
+// error that prevented reading it.
+type importRecord struct {
+	row      int
+	activity *types.ActivityRecord
+	err      error
+}
+
+// ImportActivities records the finished activities read from body, in CSV or
+// JSON lines format. Categories may be given by name, times without offset
+// are read in the timezone of the row or else in timezone, and each row is
+// validated as AddActivity does, including overlaps with the rows before it.
+// Rows equal to an existing activity are skipped, and on a dry run nothing is
+// written.
+func (s *activitiesService) ImportActivities(ctx context.Context, userID int64, format string, body io.Reader, timezone string, dryRun bool) (*types.ImportResult, error) {
+	location, err := locationOf(timezone, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err.Error())
+	}
+
+	var records []*importRecord
+	switch format {
+	case types.ImportFormatCSV:
+		records, err = s.decodeCSV(body, location)
+	case types.ImportFormatJSON:
+		records, err = decodeJSONLines(body)
+	default:
+		err = fmt.Errorf("%w: unsupported format %q", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoriesByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result   = &types.ImportResult{DryRun: dryRun, Rows: make([]*types.ImportRow, 0, len(records))}
+		accepted = make([]*importedActivity, 0, len(records))
+	)
+
+	for _, record := range records {
+		row := &types.ImportRow{Row: record.row}
+		result.Rows = append(result.Rows, row)
+
+		activity, duplicate, err := s.importActivity(ctx, userID, record, categories, accepted, dryRun)
+		switch {
+		case errors.Is(err, ErrInvalidActivity), errors.Is(err, ErrActivityOverlap):
+			row.Status = types.ImportStatusFailed
+			row.Error = err.Error()
+			result.Failed++
+		case err != nil:
+			return nil, err
+		case duplicate:
+			row.Status = types.ImportStatusSkipped
+			row.ID = activity.ID
+			result.Skipped++
+		default:
+			row.Status = types.ImportStatusImported
+			row.ID = activity.ID
+			result.Imported++
+			accepted = append(accepted, &importedActivity{row: record.row, activity: activity})
+		}
+	}
+
+	return result, nil
+}
+
+type importedActivity struct {
+	row      int
+	activity *models.Activity
+}
+
+// importActivity validates the record and creates its activity, unless it is
+// a dry run or the activity already exists, in which case the existing one is
+// returned.
+func (s *activitiesService) importActivity(
+	ctx context.Context,
+	userID int64,
+	record *importRecord,
+	categories map[string]int64,
+	accepted []*importedActivity,
+	dryRun bool,
+) (*models.Activity, bool, error) {
+	if record.err != nil {
+		return nil, false, fmt.Errorf("%w: %s", ErrInvalidActivity, record.err.Error())
+	}
+
+	input := record.activity
+	if name := strings.ToLower(strings.TrimSpace(input.Category)); name != "" {
+		id, ok := categories[name]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: unknown category %q", ErrInvalidActivity, input.Category)
+		}
+		input.CategoryID = id
+	}
+
+	activity, category, err := s.newFinishedActivity(ctx, userID, &input.Activity)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		startedAt  = activity.StartedAt.Time
+		finishedAt = activity.FinishedAt.Time
+	)
+
+	overlapping, err := s.activitiesRepository.GetOverlapping(ctx, userID, startedAt, finishedAt, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, item := range overlapping {
+		if sameActivity(item, activity) {
+			return item, true, nil
+		}
+	}
+	if len(overlapping) > 0 {
+		return nil, false, overlapError(overlapping)
+	}
+
+	// on a dry run the accepted rows are not in the database
+	for _, item := range accepted {
+		if sameActivity(item.activity, activity) {
+			return item.activity, true, nil
+		}
+		if item.activity.StartedAt.Time.Before(finishedAt) && item.activity.FinishedAt.Time.After(startedAt) {
+			return nil, false, fmt.Errorf("%w: row %d", ErrActivityOverlap, item.row)
+		}
+	}
+
+	if dryRun {
+		return activity, false, nil
+	}
+
+	overlapping, err = s.activitiesRepository.Add(ctx, activity)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(overlapping) > 0 {
+		return nil, false, overlapError(overlapping)
+	}
+
+	log.Printf("activity imported: ID=%d\n", activity.ID)
+
+	s.obs.Count("imported", category.Name)
+
+	return activity, false, nil
+}
+
+// sameActivity reports whether the activities are in the same category, with
+// the same description and times. Times are compared to the second, the
+// precision of exported files, since activities started by the timer are
+// stored with fractions of a second.
+func sameActivity(a, b *models.Activity) bool {
+	return a.CategoryID == b.CategoryID &&
+		a.Description == b.Description &&
+		a.StartedAt.Time.Truncate(time.Second).Equal(b.StartedAt.Time.Truncate(time.Second)) &&
+		a.FinishedAt.Time.Truncate(time.Second).Equal(b.FinishedAt.Time.Truncate(time.Second))
+}
+
+func (s *activitiesService) categoriesByName(ctx context.Context) (map[string]int64, error) {
+	categories, err := s.categoriesService.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int64, len(categories))
+	for _, category := range categories {
+		byName[category.Name] = category.ID
+	}
+	return byName, nil
+}
+
+// decodeCSV reads the rows of a CSV file whose header names the columns. The
+// columns category_id or category, started_at and finished_at are required,
+// description and timezone are optional and any other column is ignored, so
+// that exported files, whose formula-like cells are escaped, can be imported
+// back.
+func (s *activitiesService) decodeCSV(body io.Reader, location *time.Location) ([]*importRecord, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %s", ErrInvalidImport, err.Error())
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasCategoryID := columns["category_id"]
+	_, hasCategory := columns["category"]
+	_, hasStartedAt := columns["started_at"]
+	_, hasFinishedAt := columns["finished_at"]
+	if !(hasCategoryID || hasCategory) || !hasStartedAt || !hasFinishedAt {
+		return nil, fmt.Errorf("%w: header must have category_id or category, started_at and finished_at", ErrInvalidImport)
+	}
+
+	var records []*importRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err.Error())
+			}
+			records = append(records, &importRecord{row: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+		// rows are numbered by the line they start on, as quoted fields may
+		// span several lines
+		row, _ := reader.FieldPos(0)
+		record := &importRecord{row: row}
+		records = append(records, record)
+		record.activity, record.err = s.decodeCSVRecord(columns, fields, location)
+	}
+}
+
+func (s *activitiesService) decodeCSVRecord(columns map[string]int, fields []string, location *time.Location) (*types.ActivityRecord, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	record := &types.ActivityRecord{Category: httpext.UnescapeCSV(field("category"))}
+	record.Description = httpext.UnescapeCSV(field("description"))
+
+	if value := field("category_id"); value != "" && record.Category == "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category_id: %q", value)
+		}
+		record.CategoryID = id
+	}
+
+	location, err := timeext.LoadLocation(field("timezone"), location)
+	if err != nil {
+		return nil, err
+	}
+	for name, target := range map[string]**time.Time{
+		"started_at":  &record.StartedAt,
+		"finished_at": &record.FinishedAt,
+	} {
+		value := field(name)
+		if value == "" {
+			continue
+		}
+		t, err := timeext.Parse(s.clock, value, location)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		*target = &t
+	}
+
+	return record, nil
+}
+
+// decodeJSONLines reads one types.ActivityRecord per line, ignoring blank
+// lines.
+func decodeJSONLines(body io.Reader) ([]*importRecord, error) {
+	var (
+		records []*importRecord
+		scanner = bufio.NewScanner(body)
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		record := &importRecord{row: line, activity: new(types.ActivityRecord)}
+		if err := json.Unmarshal([]byte(text), record.activity); err != nil {
+			record.err = err
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err.Error())
+	}
+
+	return records, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxProjectNameLength = 100
+
+var (
+	ErrInvalidProject  = NewError(KindValidation, "invalid_project", "invalid project")
+	ErrProjectExists   = NewError(KindConflict, "project_exists", "project already exists")
+	ErrProjectInUse    = NewError(KindConflict, "project_in_use", "project is referenced by activities")
+	ErrProjectNotFound = NewError(KindNotFound, "project_not_found", "project not found")
+)
+
+type ProjectsService interface {
+	CreateProject(ctx context.Context, project *types.Project) (*types.Project, error)
+	GetProject(ctx context.Context, id int64) (*types.Project, error)
+	GetProjects(ctx context.Context, clientID int64) ([]*types.Project, error)
+	UpdateProject(ctx context.Context, project *types.Project) (*types.Project, error)
+	DeleteProject(ctx context.Context, id int64) error
+}
+
+type projectsService struct {
+	clientsService     ClientsService
+	projectsRepository repository.ProjectsRepository
+	mutex              *sync.RWMutex
+	cache              map[int64]*models.Project
+}
+
+// NewProjectsService creates the projects service, which caches the projects
+// as the categories service does.
+func NewProjectsService(clientsService ClientsService, projectsRepository repository.ProjectsRepository) ProjectsService {
+	svc := &projectsService{
+		clientsService:     clientsService,
+		projectsRepository: projectsRepository,
+	}
+	svc.load()
+	return svc
+}
+
+func (s *projectsService) load() {
+	projects, err := s.projectsRepository.GetAll(context.Background(), 0)
+	if err != nil {
+		log.Panicln("unable to cache projects:", err.Error())
+	}
+	s.mutex = &sync.RWMutex{}
+	s.cache = make(map[int64]*models.Project, len(projects))
+	for _, project := range projects {
+		s.cache[project.ID] = project
+	}
+}
+
+func (s *projectsService) CreateProject(ctx context.Context, project *types.Project) (*types.Project, error) {
+	name, err := s.validate(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	newProject := &models.Project{
+		ClientID:    clientIDOf(project),
+		Name:        name,
+		Description: strings.TrimSpace(project.Description),
+	}
+
+	now := time.Now()
+	newProject.SetCreatedAt(now)
+	newProject.SetUpdatedAt(now)
+
+	newProject, err = s.projectsRepository.Create(ctx, newProject)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrProjectExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[newProject.ID] = newProject
+	s.mutex.Unlock()
+
+	log.Printf("project created: ID=%d\n", newProject.ID)
+
+	return newProject.Type(), nil
+}
+
+func (s *projectsService) GetProject(ctx context.Context, id int64) (*types.Project, error) {
+	s.mutex.RLock()
+	project, ok := s.cache[id]
+	s.mutex.RUnlock()
+	if ok {
+		return project.Type(), nil
+	}
+
+	project, err := s.projectsRepository.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrProjectNotFound, id)
+	}
+
+	s.mutex.Lock()
+	s.cache[project.ID] = project
+	s.mutex.Unlock()
+
+	return project.Type(), nil
+}
+
+// GetProjects returns every project, or only the ones of the client if
+// clientID is set.
+func (s *projectsService) GetProjects(ctx context.Context, clientID int64) ([]*types.Project, error) {
+	items, err := s.projectsRepository.GetAll(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	projects := make([]*types.Project, 0, len(items))
+	for _, item := range items {
+		projects = append(projects, item.Type())
+	}
+	return projects, nil
+}
+
+func (s *projectsService) UpdateProject(ctx context.Context, project *types.Project) (*types.Project, error) {
+	existing, err := s.projectsRepository.Get(ctx, project.ID)
+	if err != nil {
+		return nil, notFound(err, ErrProjectNotFound, project.ID)
+	}
+
+	name, err := s.validate(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.ClientID = clientIDOf(project)
+	existing.Name = name
+	existing.Description = strings.TrimSpace(project.Description)
+	existing.SetUpdatedAt(time.Now())
+
+	_, err = s.projectsRepository.Update(ctx, existing)
+	if db.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrProjectExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[existing.ID] = existing
+	s.mutex.Unlock()
+
+	log.Printf("project updated: ID=%d\n", existing.ID)
+
+	return existing.Type(), nil
+}
+
+// DeleteProject removes the project id, which is refused with
+// ErrProjectInUse while it still has activities.
+func (s *projectsService) DeleteProject(ctx context.Context, id int64) error {
+	existing, err := s.projectsRepository.Get(ctx, id)
+	if err != nil {
+		return notFound(err, ErrProjectNotFound, id)
+	}
+
+	rows, count, err := s.projectsRepository.Delete(ctx, existing.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d activities", ErrProjectInUse, count)
+	}
+
+	s.mutex.Lock()
+	delete(s.cache, existing.ID)
+	s.mutex.Unlock()
+
+	if rows > 0 {
+		log.Printf("project deleted: ID=%d\n", existing.ID)
+	}
+
+	return nil
+}
+
+func (s *projectsService) validate(ctx context.Context, project *types.Project) (string, error) {
+	name := strings.TrimSpace(project.Name)
+	if name == "" {
+		return "", invalidField(ErrInvalidProject, "name", "is required")
+	}
+	if len(name) > maxProjectNameLength {
+		return "", invalidField(ErrInvalidProject, "name", "must have at most %d characters", maxProjectNameLength)
+	}
+
+	if project.ClientID != 0 {
+		_, err := s.clientsService.GetClient(ctx, project.ClientID)
+		if errors.Is(err, ErrClientNotFound) {
+			return "", invalidField(ErrInvalidProject, "client_id", "must be an existing client, %d is not", project.ClientID)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, cached := range s.cache {
+		if strings.EqualFold(cached.Name, name) && cached.ID != project.ID {
+			return "", fmt.Errorf("%w: %s", ErrProjectExists, name)
+		}
+	}
+
+	return name, nil
+}
+
+func clientIDOf(project *types.Project) sql.NullInt64 {
+	return sql.NullInt64{Int64: project.ClientID, Valid: project.ClientID != 0}
+}
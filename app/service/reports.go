@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/pointer"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ReportsService interface {
+	Summary(ctx context.Context, userID int64, filter *types.PeriodFilter) (*types.Summary, error)
+	Billing(ctx context.Context, userID int64, filter *types.BillingFilter) (*types.Billing, error)
+}
+
+type reportsService struct {
+	reportsRepository repository.ReportsRepository
+	location          *time.Location
+	rounding          timeext.Rounding
+	clock             timeext.Clock
+}
+
+// NewReportsService creates the reports service. Periods are resolved in
+// location unless the filter sets a timezone, and billed durations are
+// rounded by rounding unless the filter sets a rule.
+func NewReportsService(reportsRepository repository.ReportsRepository, location *time.Location, rounding timeext.Rounding, clock timeext.Clock) ReportsService {
+	return &reportsService{
+		reportsRepository: reportsRepository,
+		location:          location,
+		rounding:          rounding,
+		clock:             clock,
+	}
+}
+
+// Summary returns the time tracked by the user in each category and tag over
+// the period of the filter, in total and per day, only in the activities with
+// the tag, project or client of the filter if they are set.
+func (s *reportsService) Summary(ctx context.Context, userID int64, filter *types.PeriodFilter) (*types.Summary, error) {
+	period, err := periodOf(filter)
+	if err != nil {
+		return nil, err
+	}
+	location, err := locationOf(filter.Timezone, s.location)
+	if err != nil {
+		return nil, err
+	}
+	activityFilter, err := activityFilterOf(&filter.ActivityFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now        = s.clock.Now()
+		start, end = period.Range(s.clock, location)
+	)
+	days, err := s.reportsRepository.Summarize(ctx, userID, start, end, now, location, activityFilter)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.reportsRepository.SummarizeTags(ctx, userID, start, end, now, activityFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.Summary{
+		From:       pointer.New(start),
+		To:         pointer.New(end),
+		Categories: make([]*types.CategorySummary, 0),
+		Tags:       make([]*types.TagSummary, 0, len(tags)),
+		Days:       make([]*types.DaySummary, 0),
+	}
+
+	for _, item := range tags {
+		summary.Tags = append(summary.Tags, &types.TagSummary{
+			Tag:      item.Tag,
+			Count:    item.Count,
+			Duration: int64(math.Round(item.Seconds)),
+		})
+	}
+	sort.SliceStable(summary.Tags, func(i, j int) bool {
+		return summary.Tags[i].Duration > summary.Tags[j].Duration
+	})
+
+	byCategory := make(map[int64]*types.CategorySummary)
+	for _, item := range days {
+		duration := seconds(item)
+
+		category, ok := byCategory[item.CategoryID]
+		if !ok {
+			category = &types.CategorySummary{CategoryID: item.CategoryID, Category: item.Category}
+			byCategory[item.CategoryID] = category
+			summary.Categories = append(summary.Categories, category)
+		}
+		category.Count += item.Count
+		category.Duration += duration
+
+		// items are ordered by day
+		days := len(summary.Days)
+		if days == 0 || summary.Days[days-1].Date != item.Date {
+			summary.Days = append(summary.Days, &types.DaySummary{Date: item.Date})
+		}
+		day := summary.Days[len(summary.Days)-1]
+		day.Count += item.Count
+		day.Duration += duration
+		day.Categories = append(day.Categories, &types.CategorySummary{
+			CategoryID: item.CategoryID,
+			Category:   item.Category,
+			Count:      item.Count,
+			Duration:   duration,
+		})
+
+		summary.Count += item.Count
+		summary.Duration += duration
+	}
+
+	rank(summary.Categories, summary.Duration)
+	for _, day := range summary.Days {
+		rank(day.Categories, day.Duration)
+	}
+
+	return summary, nil
+}
+
+// Billing returns the billable hours and amounts of the user in each category
+// over the period of the filter, only in the activities with the tag, project
+// or client of the filter if they are set. Each activity is rounded before it
+// is billed, so the totals add up the activities rather than being summed in
+// the query.
+func (s *reportsService) Billing(ctx context.Context, userID int64, filter *types.BillingFilter) (*types.Billing, error) {
+	period, err := periodOf(&filter.PeriodFilter)
+	if err != nil {
+		return nil, err
+	}
+	location, err := locationOf(filter.Timezone, s.location)
+	if err != nil {
+		return nil, err
+	}
+	activityFilter, err := activityFilterOf(&filter.ActivityFilter)
+	if err != nil {
+		return nil, err
+	}
+	rounding := s.rounding
+	if strings.TrimSpace(filter.Rounding) != "" {
+		rounding, err = timeext.ParseRounding(filter.Rounding)
+		if err != nil {
+			return nil, invalidField(ErrInvalidFilter, "rounding", "must be none or MODE:INCREMENT, such as up:15m, not %q", filter.Rounding)
+		}
+	}
+
+	start, end := period.Range(s.clock, location)
+	entries, err := s.reportsRepository.Billable(ctx, userID, start, end, s.clock.Now(), activityFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	billing := &types.Billing{
+		From:       pointer.New(start),
+		To:         pointer.New(end),
+		Rounding:   rounding.String(),
+		Categories: make([]*types.CategoryBilling, 0),
+	}
+
+	byCategory := make(map[int64]*types.CategoryBilling)
+	for _, entry := range entries {
+		var (
+			duration = rounding.Round(time.Duration(math.Round(entry.Seconds)) * time.Second)
+			amount   = int64(math.Round(duration.Hours() * float64(entry.HourlyRate)))
+		)
+
+		category, ok := byCategory[entry.CategoryID]
+		if !ok {
+			category = &types.CategoryBilling{CategoryID: entry.CategoryID, Category: entry.Category}
+			byCategory[entry.CategoryID] = category
+			billing.Categories = append(billing.Categories, category)
+		}
+		category.Count++
+		category.Duration += int64(duration.Seconds())
+		category.Amount += amount
+
+		billing.Count++
+		billing.Duration += int64(duration.Seconds())
+		billing.Amount += amount
+	}
+
+	for _, category := range billing.Categories {
+		category.Hours = hours(category.Duration)
+	}
+	billing.Hours = hours(billing.Duration)
+
+	return billing, nil
+}
+
+// hours returns the seconds as hours rounded to the hundredth.
+func hours(seconds int64) float64 {
+	return math.Round(float64(seconds)/3600*100) / 100
+}
+
+func seconds(summary *models.CategoryDaySummary) int64 {
+	return int64(math.Round(summary.Seconds))
+}
+
+// rank sets the fraction of total tracked in each category and sorts them
+// from the most to the least tracked.
+func rank(categories []*types.CategorySummary, total int64) {
+	for _, category := range categories {
+		if total > 0 {
+			category.Share = math.Round(float64(category.Duration)/float64(total)*10000) / 10000
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].Duration > categories[j].Duration
+	})
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchTerms     = 10
+)
+
+var ErrInvalidSearch = NewError(KindBadRequest, "invalid_search", "invalid search")
+
+type SearchService interface {
+	SearchActivities(ctx context.Context, userID int64, filter *types.SearchFilter) ([]*types.SearchResult, error)
+}
+
+type searchService struct {
+	searchRepository repository.SearchRepository
+	location         *time.Location
+	clock            timeext.Clock
+}
+
+// NewSearchService creates the search service. Periods are resolved in
+// location unless the filter sets a timezone.
+func NewSearchService(searchRepository repository.SearchRepository, location *time.Location, clock timeext.Clock) SearchService {
+	return &searchService{
+		searchRepository: searchRepository,
+		location:         location,
+		clock:            clock,
+	}
+}
+
+// SearchActivities returns the activities of the user with any of the words
+// of the query in their description, tags or category name, from the best
+// match. Every activity of the user is searched unless the filter sets a
+// period, a tag, a project or a client.
+func (s *searchService) SearchActivities(ctx context.Context, userID int64, filter *types.SearchFilter) ([]*types.SearchResult, error) {
+	terms := searchTermsOf(filter.Query)
+	if len(terms) == 0 {
+		return nil, invalidField(ErrInvalidSearch, "q", "must have a word")
+	}
+
+	limit := defaultSearchLimit
+	if filter.Limit != 0 {
+		limit = filter.Limit
+	}
+	if limit < 0 || limit > maxSearchLimit {
+		return nil, invalidField(ErrInvalidSearch, "limit", "must be between 1 and %d", maxSearchLimit)
+	}
+
+	activityFilter, err := activityFilterOf(&filter.ActivityFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	if filter.PeriodName != "" || filter.From != "" || filter.To != "" {
+		period, err := periodOf(&filter.PeriodFilter)
+		if err != nil {
+			return nil, err
+		}
+		location, err := locationOf(filter.Timezone, s.location)
+		if err != nil {
+			return nil, err
+		}
+		start, end = period.Range(s.clock, location)
+	}
+
+	hits, err := s.searchRepository.Search(ctx, userID, terms, start, end, activityFilter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	highlighter := highlighterOf(terms)
+	results := make([]*types.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, &types.SearchResult{
+			Activity:  *hit.Activity.Type(now),
+			Category:  hit.Category,
+			Score:     math.Round(hit.Score*10000) / 10000,
+			Highlight: highlighter.ReplaceAllString(hit.Activity.Description, "<mark>$0</mark>"),
+		})
+	}
+	return results, nil
+}
+
+// searchTermsOf returns the distinct words of the query in lower case,
+// without the punctuation around them, such as the # of tags.
+func searchTermsOf(query string) []string {
+	var (
+		terms = make([]string, 0, maxSearchTerms)
+		seen  = make(map[string]bool, maxSearchTerms)
+	)
+	for _, word := range strings.Fields(query) {
+		term := strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}))
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// highlighterOf returns an expression of the terms in any case, longest first
+// so that they are highlighted whole.
+func highlighterOf(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	sort.SliceStable(quoted, func(i, j int) bool {
+		return len(quoted[i]) > len(quoted[j])
+	})
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/ungame/timetrack/types"
+	"sort"
+	"strings"
+)
+
+// normalizeTags returns the tags normalized, sorted and without repetitions.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name, ok := types.NormalizeTag(tag)
+		if !ok {
+			return nil, fmt.Errorf("%w: tag %q must have up to %d letters, digits, - or _", ErrInvalidActivity, tag, types.MaxTagLength)
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func formatTags(tags []string) sql.NullString {
+	return sql.NullString{String: strings.Join(tags, ","), Valid: true}
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
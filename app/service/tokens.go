@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"strings"
+	"time"
+)
+
+// TokenPrefix starts the secrets of the tokens, which makes them easy to tell
+// apart from other credentials, e.g. by secret scanners.
+const TokenPrefix = "tt_"
+
+var (
+	ErrInvalidToken  = NewError(KindValidation, "invalid_token", "invalid token")
+	ErrTokenRevoked  = NewError(KindConflict, "token_revoked", "token already revoked")
+	ErrTokenNotFound = NewError(KindNotFound, "token_not_found", "token not found")
+	// ErrUnknownToken is the error of requests authenticated by a token that
+	// doesn't exist or was revoked.
+	ErrUnknownToken = NewError(KindUnauthorized, "unknown_token", "unknown token")
+)
+
+type TokensService interface {
+	CreateToken(ctx context.Context, userID int64, token *types.Token) (*types.Token, error)
+	GetTokens(ctx context.Context, userID int64) ([]*types.Token, error)
+	RevokeToken(ctx context.Context, userID, id int64) (*types.Token, error)
+	Authenticate(ctx context.Context, secret string) (*types.Token, error)
+}
+
+type tokensService struct {
+	tokensRepository repository.TokensRepository
+}
+
+func NewTokensService(tokensRepository repository.TokensRepository) TokensService {
+	return &tokensService{tokensRepository: tokensRepository}
+}
+
+// CreateToken creates a token of the user with a random secret, which is
+// returned once and only stored as its hash. The scope defaults to read.
+func (s *tokensService) CreateToken(ctx context.Context, userID int64, token *types.Token) (*types.Token, error) {
+	name := strings.TrimSpace(token.Name)
+	if name == "" || len(name) > 50 {
+		return nil, invalidField(ErrInvalidToken, "name", "must have from 1 to 50 characters")
+	}
+
+	scope := strings.ToLower(strings.TrimSpace(token.Scope))
+	if scope == "" {
+		scope = types.ScopeRead
+	}
+	if scope != types.ScopeRead && scope != types.ScopeWrite {
+		return nil, invalidField(ErrInvalidToken, "scope", "must be %s or %s", types.ScopeRead, types.ScopeWrite)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	newToken := &models.Token{
+		UserID: userID,
+		Name:   name,
+		Hash:   hashSecret(secret),
+		Scope:  scope,
+	}
+	newToken.SetCreatedAt(time.Now())
+
+	newToken, err = s.tokensRepository.Create(ctx, newToken)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("token created: ID=%d, UserID=%d, Scope=%s\n", newToken.ID, userID, scope)
+
+	created := newToken.Type()
+	created.Secret = secret
+	return created, nil
+}
+
+func (s *tokensService) GetTokens(ctx context.Context, userID int64) ([]*types.Token, error) {
+	items, err := s.tokensRepository.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]*types.Token, 0, len(items))
+	for _, item := range items {
+		tokens = append(tokens, item.Type())
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes the token of the user, which is kept to list it but no
+// longer authenticates requests.
+func (s *tokensService) RevokeToken(ctx context.Context, userID, id int64) (*types.Token, error) {
+	token, err := s.tokensRepository.Get(ctx, userID, id)
+	if err != nil {
+		return nil, notFound(err, ErrTokenNotFound, id)
+	}
+	if token.Revoked() {
+		return nil, fmt.Errorf("%w: %d", ErrTokenRevoked, id)
+	}
+
+	token.SetRevokedAt(time.Now())
+	rows, err := s.tokensRepository.Revoke(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrTokenRevoked, id)
+	}
+
+	log.Printf("token revoked: ID=%d, UserID=%d\n", id, userID)
+
+	return token.Type(), nil
+}
+
+// Authenticate returns the token of the secret, or ErrUnknownToken when
+// there is none or it was revoked.
+func (s *tokensService) Authenticate(ctx context.Context, secret string) (*types.Token, error) {
+	if !strings.HasPrefix(secret, TokenPrefix) {
+		return nil, ErrUnknownToken
+	}
+	token, err := s.tokensRepository.GetByHash(ctx, hashSecret(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.Revoked() {
+		return nil, ErrUnknownToken
+	}
+	return token.Type(), nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return TokenPrefix + hex.EncodeToString(b), nil
+}
+
+// hashSecret returns the SHA-256 of the secret, which has enough entropy not
+// to need a slow hash.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/ungame/timetrack/app/models"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/types"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidUser  = NewError(KindValidation, "invalid_user", "invalid user")
+	ErrUserExists   = NewError(KindConflict, "user_exists", "user already exists")
+	ErrUserNotFound = NewError(KindNotFound, "user_not_found", "user not found")
+	// ErrUnknownUser is the error of requests naming a user that doesn't
+	// exist, unlike ErrUserNotFound of the requests for a user.
+	ErrUnknownUser = NewError(KindUnauthorized, "unknown_user", "unknown user")
+)
+
+// userName matches the names of users, which are sent in a header by the
+// clients.
+var userName = regexp.MustCompile(`^[a-z0-9][a-z0-9._@-]{0,49}$`)
+
+type UsersService interface {
+	CreateUser(ctx context.Context, user *types.User) (*types.User, error)
+	GetUser(ctx context.Context, id int64) (*types.User, error)
+	GetUserByName(ctx context.Context, name string) (*types.User, error)
+	GetUsers(ctx context.Context) ([]*types.User, error)
+}
+
+type usersService struct {
+	usersRepository repository.UsersRepository
+}
+
+func NewUsersService(usersRepository repository.UsersRepository) UsersService {
+	return &usersService{usersRepository: usersRepository}
+}
+
+// CreateUser creates a user named by a lower case name of letters, digits,
+// dots, dashes, underscores and at signs, such as an email address.
+func (s *usersService) CreateUser(ctx context.Context, user *types.User) (*types.User, error) {
+	name := strings.ToLower(strings.TrimSpace(user.Name))
+	if !userName.MatchString(name) {
+		return nil, invalidField(ErrInvalidUser, "name", "must have up to 50 letters, digits or ._@- characters")
+	}
+
+	_, err := s.usersRepository.GetByName(ctx, name)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, name)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	newUser := &models.User{Name: name}
+
+	now := time.Now()
+	newUser.SetCreatedAt(now)
+	newUser.SetUpdatedAt(now)
+
+	newUser, err = s.usersRepository.Create(ctx, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("user created: ID=%d\n", newUser.ID)
+
+	return newUser.Type(), nil
+}
+
+func (s *usersService) GetUser(ctx context.Context, id int64) (*types.User, error) {
+	user, err := s.usersRepository.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound, id)
+	}
+	return user.Type(), nil
+}
+
+// GetUserByName returns the user of the name, ignoring case, or
+// ErrUnknownUser when there is none.
+func (s *usersService) GetUserByName(ctx context.Context, name string) (*types.User, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	user, err := s.usersRepository.GetByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownUser, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user.Type(), nil
+}
+
+func (s *usersService) GetUsers(ctx context.Context) ([]*types.User, error) {
+	items, err := s.usersRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*types.User, 0, len(items))
+	for _, item := range items {
+		users = append(users, item.Type())
+	}
+	return users, nil
+}
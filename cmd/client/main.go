@@ -2,17 +2,84 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"github.com/ungame/timetrack/app/cli"
+	"github.com/ungame/timetrack/config"
+	"github.com/ungame/timetrack/timeext"
+	"log"
+	"os"
+	"time"
 )
 
-var baseURL string
+// envClientConfig names the config file of the client, whose keys are the
+// ones of clientKeys.
+const envClientConfig = config.EnvPrefix + "CLIENT_CONFIG"
+
+// clientKeys are the flags of the settings by their key in the config file.
+var clientKeys = map[string]string{
+	"base_url": "base_url",
+	"timezone": "tz",
+	"user":     "u",
+	"token":    "token",
+}
+
+var (
+	baseURL  string
+	timezone string
+	user     string
+	token    string
+	file     string
+)
 
 func init() {
+	flag.StringVar(&file, "config", os.Getenv(envClientConfig), "set config file, YAML or TOML, with base_url, timezone, user and token")
 	flag.StringVar(&baseURL, "base_url", "http://localhost:15555", "set base url server")
+	flag.StringVar(&timezone, "tz", os.Getenv("TZ"), "set timezone, defaults to the local one")
+	flag.StringVar(&user, "u", os.Getenv("TIMETRACK_USER"), "set user name, defaults to the default user")
+	flag.StringVar(&token, "token", os.Getenv("TIMETRACK_TOKEN"), "set API token of a server run with -auth")
 	flag.Parse()
 }
 
 func main() {
-	c := cli.New(baseURL)
+	if err := loadConfig(); err != nil {
+		log.Fatalln("invalid config:", err.Error())
+	}
+	location, err := timeext.LoadLocation(timezone, time.Local)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	c := cli.New(baseURL, location, user, token)
 	c.Run()
 }
+
+// loadConfig applies the config file to the settings without a flag or an
+// environment variable.
+func loadConfig() error {
+	if file == "" {
+		return nil
+	}
+	values, err := config.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	fromEnv := map[string]string{"tz": "TZ", "u": "TIMETRACK_USER", "token": "TIMETRACK_TOKEN"}
+
+	for key, value := range values {
+		name, ok := clientKeys[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", file, key)
+		}
+		if explicit[name] || os.Getenv(fromEnv[name]) != "" {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
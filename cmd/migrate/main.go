@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/ungame/timetrack/config"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/timeext"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	var steps int
+	flag.IntVar(&steps, "n", 1, "set the number of versions to revert with down")
+	flag.Usage = usage
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fail(err)
+	}
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	var (
+		conn    *sql.DB
+		dialect = cfg.Database.Dialect
+	)
+	if cfg.Lite {
+		conn = db.Lite(db.NewFileStorage(cfg.Database.Path))
+		dialect = db.SQLite
+	} else {
+		conn = db.New(cfg.Database)
+	}
+	defer ioext.Close(conn)
+
+	migrator, err := db.NewMigrator(conn, dialect)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute+cfg.Database.Timeout)
+	defer cancel()
+
+	switch flag.Arg(0) {
+	case "up":
+		ran, err := migrator.Up(ctx)
+		printVersions("applied", ran)
+		if err != nil {
+			fail(err)
+		}
+		if cfg.Lite {
+			db.NewSearchMigration().Up(conn)
+		}
+
+	case "down":
+		ran, err := migrator.Down(ctx, steps)
+		printVersions("reverted", ran)
+		if err != nil {
+			fail(err)
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fail(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED\t")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Local().Format(timeext.DateTimeFormat)
+			}
+			_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\t%s\t\n", status.Number, status.Name, status.State, appliedAt)
+		}
+		_ = w.Flush()
+
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func printVersions(action string, versions []*db.Version) {
+	if len(versions) == 0 {
+		fmt.Println("no versions", action)
+	}
+	for _, version := range versions {
+		fmt.Println(action+":", version)
+	}
+}
+
+func fail(err error) {
+	fmt.Println("[ERROR]", err.Error())
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Println("-- Usage:")
+	fmt.Println(" ")
+	fmt.Println("     migrate up")
+	fmt.Println("              apply the pending versions of the schema")
+	fmt.Println("")
+	fmt.Println("     migrate -n STEPS down")
+	fmt.Println("              revert the last STEPS applied versions, defaults to 1")
+	fmt.Println("")
+	fmt.Println("     migrate status")
+	fmt.Println("              list the versions, applied, pending, modified or missing")
+	fmt.Println("")
+	fmt.Println("     the database is the one of cmd/server, set by -l, -config, -db, -db-dsn, -db-path")
+	fmt.Println("     or their TIMETRACK_* environment variables")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/app/service"
+	"github.com/ungame/timetrack/config"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/ioext"
+	"github.com/ungame/timetrack/types"
+	"os"
+)
+
+func main() {
+	var (
+		user  string
+		name  string
+		scope string
+	)
+	flag.StringVar(&user, "u", "default", "set user name of the token")
+	flag.StringVar(&name, "name", "", "set name of the token, e.g. laptop")
+	flag.StringVar(&scope, "scope", types.ScopeWrite, "set scope of the token, read or write")
+	flag.Usage = usage
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fail(err)
+	}
+
+	if flag.NArg() != 1 || flag.Arg(0) != "create" {
+		usage()
+		os.Exit(2)
+	}
+
+	var (
+		conn    *sql.DB
+		dialect = cfg.Database.Dialect
+	)
+	if cfg.Lite {
+		conn = db.Lite(db.NewFileStorage(cfg.Database.Path), db.Migrations(db.SQLite))
+		dialect = db.SQLite
+	} else {
+		conn = db.New(cfg.Database)
+	}
+	defer ioext.Close(conn)
+
+	var (
+		ctx           = context.Background()
+		usersService  = service.NewUsersService(repository.NewUsersRepository(conn, dialect))
+		tokensService = service.NewTokensService(repository.NewTokensRepository(conn, dialect))
+	)
+
+	owner, err := usersService.GetUserByName(ctx, user)
+	if err != nil {
+		fail(err)
+	}
+
+	token, err := tokensService.CreateToken(ctx, owner.ID, &types.Token{Name: name, Scope: scope})
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("token %d of %s, scope %s, save it now, it can't be read again:\n", token.ID, owner.Name, token.Scope)
+	fmt.Println(token.Secret)
+}
+
+func fail(err error) {
+	fmt.Println("[ERROR]", err.Error())
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Println("-- Usage:")
+	fmt.Println(" ")
+	fmt.Println("     token -u USER -name NAME -scope SCOPE create")
+	fmt.Println("              create an API token of the user and print its secret")
+	fmt.Println("              USER  (optional): name of an existing user, defaults to default")
+	fmt.Println("              NAME  (required): e.g. laptop")
+	fmt.Println("              SCOPE (optional): [read, write], defaults to write")
+	fmt.Println("")
+	fmt.Println("     the first token of a server run with -auth, further tokens can be created at POST /tokens")
+	fmt.Println("     the database is the one of cmd/server, set by -l, -config, -db, -db-dsn, -db-path")
+	fmt.Println("     or their TIMETRACK_* environment variables")
+}
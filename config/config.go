@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ungame/timetrack/db"
+	"github.com/ungame/timetrack/httpext"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	EnvPrefix = "TIMETRACK_"
+	EnvFile   = EnvPrefix + "CONFIG"
+)
+
+// Config is the configuration of the server and of the tools sharing its
+// database. Settings are read from flags, then TIMETRACK_* environment
+// variables, then the config file, and default to the ones of Default.
+type Config struct {
+	Port     int
+	Lite     bool
+	Timezone string
+	Rounding string
+	Migrate  bool
+	Database *db.Config
+	Auth     *Auth
+	Cors     *Cors
+}
+
+// Auth is the authentication of the requests by API tokens, which is
+// disabled by default. The Exempt paths, such as the ones of the metrics and
+// of the health probes, are served without a token.
+type Auth struct {
+	Enabled bool
+	Exempt  List
+}
+
+func Default() *Config {
+	return &Config{
+		Port:     15555,
+		Lite:     true,
+		Timezone: "UTC",
+		Rounding: "none",
+		Migrate:  true,
+		Database: db.DefaultConfig(),
+		Auth: &Auth{
+			Exempt: List{"/metrics", "/healthz", "/readyz"},
+		},
+		Cors: &Cors{
+			Origins: List{"*"},
+			Methods: List{
+				http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+				http.MethodPatch, http.MethodDelete, http.MethodOptions,
+			},
+			Headers: List{
+				httpext.HeaderContentType, "X-Requested-With", "Accept",
+				httpext.HeaderAuthorization, httpext.HeaderTimezone, httpext.HeaderUser,
+			},
+			ExposedHeaders: List{httpext.HeaderLocation, httpext.HeaderEntity, httpext.HeaderLink},
+			MaxAge:         10 * time.Minute,
+		},
+	}
+}
+
+// Load registers the settings as flags of fs, parses the args and applies
+// the environment and the config file, named by -config or TIMETRACK_CONFIG,
+// to the settings without a flag. The keys of the file are the ones of
+// Keys, and the environment variables are the keys in upper case with
+// underscores, prefixed by TIMETRACK_, e.g. TIMETRACK_DATABASE_DSN.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	var (
+		cfg  = Default()
+		file string
+	)
+
+	fs.StringVar(&file, "config", os.Getenv(EnvFile), "set config file, YAML or TOML")
+	fs.IntVar(&cfg.Port, "p", cfg.Port, "set port")
+	fs.BoolVar(&cfg.Lite, "l", cfg.Lite, "set true to run lite version")
+	fs.StringVar(&cfg.Timezone, "tz", cfg.Timezone, "set default timezone of periods")
+	fs.StringVar(&cfg.Rounding, "rounding", cfg.Rounding, "set default rounding of billed activities, e.g. up:15m")
+	fs.BoolVar(&cfg.Migrate, "migrate", cfg.Migrate, "set false to skip pending migrations of the server database, see cmd/migrate")
+	fs.Var(&cfg.Database.Dialect, "db", "set database of the server version, mysql or postgres")
+	fs.StringVar(&cfg.Database.DSN, "db-dsn", cfg.Database.DSN, "set data source name of the database, defaults to the one of infra/timetrack")
+	fs.StringVar(&cfg.Database.Path, "db-path", cfg.Database.Path, "set SQLite file of the lite version")
+	fs.IntVar(&cfg.Database.MaxOpenConns, "db-max-open-conns", cfg.Database.MaxOpenConns, "set max open connections to the database")
+	fs.IntVar(&cfg.Database.MaxIdleConns, "db-max-idle-conns", cfg.Database.MaxIdleConns, "set max idle connections to the database")
+	fs.DurationVar(&cfg.Database.ConnMaxLifetime, "db-conn-max-lifetime", cfg.Database.ConnMaxLifetime, "set max lifetime of connections to the database")
+	fs.DurationVar(&cfg.Database.Timeout, "db-timeout", cfg.Database.Timeout, "set timeout to connect to the database")
+	fs.BoolVar(&cfg.Auth.Enabled, "auth", cfg.Auth.Enabled, "set true to require API tokens, see cmd/token")
+	fs.Var(&cfg.Auth.Exempt, "auth-exempt", "set comma separated paths served without API tokens, a trailing slash exempts the paths under it")
+	fs.Var(&cfg.Cors.Origins, "cors-origins", "set comma separated origins allowed to call the API from a browser, e.g. http://localhost:3000, or *")
+	fs.Var(&cfg.Cors.Methods, "cors-methods", "set comma separated methods allowed from other origins")
+	fs.Var(&cfg.Cors.Headers, "cors-headers", "set comma separated request headers allowed from other origins")
+	fs.Var(&cfg.Cors.ExposedHeaders, "cors-exposed-headers", "set comma separated response headers readable from other origins")
+	fs.BoolVar(&cfg.Cors.Credentials, "cors-credentials", cfg.Cors.Credentials, "set true to allow credentials from other origins, which must be listed")
+	fs.DurationVar(&cfg.Cors.MaxAge, "cors-max-age", cfg.Cors.MaxAge, "set how long browsers cache preflight responses, at most 10m")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	values := make(map[string]string)
+	if file != "" {
+		fileValues, err := ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			if _, ok := Keys[key]; !ok {
+				return nil, fmt.Errorf("%s: unknown setting %q", file, key)
+			}
+			values[key] = value
+		}
+	}
+	for key := range Keys {
+		if value, ok := os.LookupEnv(EnvOf(key)); ok {
+			values[key] = value
+		}
+	}
+
+	for key, value := range values {
+		name := Keys[key]
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if err := cfg.Cors.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cors: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Keys are the flags of the settings by their key in config files.
+var Keys = map[string]string{
+	"port":                       "p",
+	"lite":                       "l",
+	"timezone":                   "tz",
+	"rounding":                   "rounding",
+	"migrate":                    "migrate",
+	"database.dialect":           "db",
+	"database.dsn":               "db-dsn",
+	"database.path":              "db-path",
+	"database.max_open_conns":    "db-max-open-conns",
+	"database.max_idle_conns":    "db-max-idle-conns",
+	"database.conn_max_lifetime": "db-conn-max-lifetime",
+	"database.timeout":           "db-timeout",
+	"auth.enabled":               "auth",
+	"auth.exempt":                "auth-exempt",
+	"cors.origins":               "cors-origins",
+	"cors.methods":               "cors-methods",
+	"cors.headers":               "cors-headers",
+	"cors.exposed_headers":       "cors-exposed-headers",
+	"cors.credentials":           "cors-credentials",
+	"cors.max_age":               "cors-max-age",
+}
+
+// EnvOf returns the environment variable of the key, e.g.
+// TIMETRACK_DATABASE_DSN of database.dsn.
+func EnvOf(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Cors is the policy of the requests from browsers on other origins, such
+// as a dashboard served on another port.
+type Cors struct {
+	Origins        List
+	Methods        List
+	Headers        List
+	ExposedHeaders List
+	Credentials    bool
+	MaxAge         time.Duration
+}
+
+// MaxCorsMaxAge is the longest max age of the preflight responses, beyond
+// which browsers ignore it.
+const MaxCorsMaxAge = 10 * time.Minute
+
+// Validate checks that the origins are * or URLs without a path, such as
+// http://localhost:3000, and that credentials are only allowed to listed
+// origins, as browsers refuse them from any origin.
+func (c *Cors) Validate() error {
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			if c.Credentials {
+				return errors.New("credentials can't be allowed to any origin")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return fmt.Errorf("origin must be * or a URL such as http://localhost:3000: %q", origin)
+		}
+	}
+	if c.MaxAge < 0 || c.MaxAge > MaxCorsMaxAge {
+		return fmt.Errorf("max age must be from 0 to %s: %s", MaxCorsMaxAge, c.MaxAge)
+	}
+	return nil
+}
+
+// List is a setting of comma separated values, e.g. "/metrics, /healthz".
+type List []string
+
+func (l *List) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set replaces the values by the ones of value, ignoring the empty ones.
+func (l *List) Set(value string) error {
+	values := make(List, 0, strings.Count(value, ",")+1)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	*l = values
+	return nil
+}
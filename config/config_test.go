@@ -0,0 +1,110 @@
+package config
+
+import (
+	"flag"
+	"github.com/ungame/timetrack/db"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	file := writeFile(t, "timetrack.yaml", `# timetrack
+port: 8080
+lite: false
+database:
+  dsn: "app:secret@tcp(db:3306)/timetrack"   # MySQL
+  max_open_conns: 10
+  timeout: 3s
+timezone: America/Sao_Paulo
+`)
+	t.Setenv(EnvFile, file)
+	t.Setenv("TIMETRACK_DATABASE_MAX_OPEN_CONNS", "50")
+	t.Setenv("TIMETRACK_PORT", "9090")
+
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-p", "7070", "-rounding", "up:15m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Port != 7070 {
+		t.Errorf("unexpected port of the flag: expected=7070, got=%d", cfg.Port)
+	}
+	if cfg.Database.MaxOpenConns != 50 {
+		t.Errorf("unexpected max open conns of the environment: expected=50, got=%d", cfg.Database.MaxOpenConns)
+	}
+	if cfg.Lite || cfg.Timezone != "America/Sao_Paulo" || cfg.Database.Timeout != 3*time.Second {
+		t.Errorf("unexpected settings of the file: %+v, %+v", cfg, cfg.Database)
+	}
+	if cfg.Database.DSN != "app:secret@tcp(db:3306)/timetrack" {
+		t.Errorf("unexpected dsn: %s", cfg.Database.DSN)
+	}
+	if cfg.Rounding != "up:15m" || !cfg.Migrate || cfg.Database.MaxIdleConns != Default().Database.MaxIdleConns {
+		t.Errorf("unexpected settings: %+v, %+v", cfg, cfg.Database)
+	}
+}
+
+func TestLoad_TOML(t *testing.T) {
+	file := writeFile(t, "timetrack.toml", `port = 8080
+
+[database]
+dsn = "app:secret@tcp(db:3306)/timetrack?timeout=1s"
+conn_max_lifetime = "1m"
+
+[auth]
+enabled = true
+exempt = "/metrics, /status/"
+
+[cors]
+origins = "http://localhost:3000, https://dash.example.com"
+credentials = true
+`)
+	t.Setenv("TIMETRACK_DATABASE_DIALECT", "postgresql")
+
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.Database.DSN != "app:secret@tcp(db:3306)/timetrack?timeout=1s" || cfg.Database.ConnMaxLifetime != time.Minute || cfg.Database.Dialect != db.Postgres {
+		t.Errorf("unexpected settings: %+v, %+v", cfg, cfg.Database)
+	}
+	if !cfg.Auth.Enabled || !reflect.DeepEqual(cfg.Auth.Exempt, List{"/metrics", "/status/"}) {
+		t.Errorf("unexpected auth: %+v", cfg.Auth)
+	}
+	if !cfg.Cors.Credentials || !reflect.DeepEqual(cfg.Cors.Origins, List{"http://localhost:3000", "https://dash.example.com"}) {
+		t.Errorf("unexpected cors: %+v", cfg.Cors)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml": "database:\n  host: localhost\n",
+		"invalid.toml": "port = eighty\n",
+		"syntax.yaml":  "port\n",
+		"dialect.yaml": "database:\n  dialect: oracle\n",
+		"cors.yaml":    "cors:\n  origins: \"*\"\n  credentials: true\n",
+		"origin.yaml":  "cors:\n  origins: localhost:3000\n",
+		"max_age.toml": "[cors]\nmax_age = \"1h\"\n",
+	} {
+		file := writeFile(t, name, content)
+		if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", file}); err == nil {
+			t.Errorf("expected error loading %s", name)
+		}
+	}
+
+	t.Setenv("TIMETRACK_LITE", "maybe")
+	if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil); err == nil {
+		t.Error("expected error loading an invalid environment variable")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ungame/timetrack/ioext"
+	"os"
+	"strings"
+)
+
+// ReadFile reads the settings of a config file by their key. It reads the
+// flat subset of YAML and TOML the settings need: keys with a scalar value,
+// as "key: value" or "key = value", grouped in sections such as "database:"
+// followed by indented keys, or "[database]". Comments start with #.
+func ReadFile(name string) (map[string]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer ioext.Close(file)
+
+	var (
+		values  = make(map[string]string)
+		section string
+		scanner = bufio.NewScanner(file)
+		number  int
+	)
+	for scanner.Scan() {
+		number++
+		line := stripComment(scanner.Text())
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			continue
+		}
+
+		key, sep, value, ok := cutSetting(trimmed)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key: value or key = value", name, number)
+		}
+		indented := line != strings.TrimLeft(line, " \t")
+		switch {
+		case value == "" && !indented:
+			// a YAML section, whose keys follow indented
+			section = key
+			continue
+		case !indented && sep == ':':
+			// a YAML key after a section is at the top level again
+			section = ""
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = unquote(value)
+	}
+	return values, scanner.Err()
+}
+
+// cutSetting splits a line at the first = or :, whichever comes first, so
+// that values such as DSNs may hold the other one, and returns the separator.
+func cutSetting(line string) (string, byte, string, bool) {
+	i := strings.IndexAny(line, "=:")
+	if i <= 0 {
+		return "", 0, "", false
+	}
+	return strings.TrimSpace(line[:i]), line[i], strings.TrimSpace(line[i+1:]), true
+}
+
+// stripComment removes a # comment outside of quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -4,17 +4,25 @@ import (
 	"database/sql"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
-	"time"
 )
 
-func New() *sql.DB {
-	cfg := getDefaultConfig()
-	conn, err := sql.Open("mysql", cfg.Source())
+// New opens a connection to the MySQL or Postgres database of cfg.
+func New(cfg *Config) *sql.DB {
+	source, err := cfg.Source()
+	if err != nil {
+		log.Panicln("invalid dsn:", err.Error())
+	}
+	var conn *sql.DB
+	if cfg.Dialect == Postgres {
+		conn, err = openPostgres(source)
+	} else {
+		conn, err = sql.Open("mysql", source)
+	}
 	if err != nil {
 		log.Panicln(err)
 	}
-	conn.SetConnMaxLifetime(time.Minute * 5)
-	conn.SetMaxIdleConns(25)
-	conn.SetMaxOpenConns(25)
+	conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
 	return conn
 }
@@ -3,15 +3,30 @@ package db
 import (
 	"context"
 	"github.com/ungame/timetrack/ioext"
+	"os"
 	"testing"
-	"time"
 )
 
+// TestNew pings the database of TIMETRACK_DATABASE_DSN, the environment
+// variable of the database.dsn setting, which is MySQL unless
+// TIMETRACK_DATABASE_DIALECT is postgres.
 func TestNew(t *testing.T) {
-	conn := New()
+	dsn := os.Getenv("TIMETRACK_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TIMETRACK_DATABASE_DSN is not set")
+	}
+	cfg := DefaultConfig()
+	cfg.DSN = dsn
+	if dialect := os.Getenv("TIMETRACK_DATABASE_DIALECT"); dialect != "" {
+		if err := cfg.Dialect.Set(dialect); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn := New(cfg)
 	defer ioext.Close(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
 
 	err := conn.PingContext(ctx)
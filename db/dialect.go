@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavour of a connection, for the few queries that
+// cannot be written portably.
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite3"
+	Postgres Dialect = "postgres"
+)
+
+// ParseDialect returns the dialect of a server database, MySQL or Postgres.
+func ParseDialect(name string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(name))); d {
+	case MySQL, Postgres:
+		return d, nil
+	case "postgresql", "pg":
+		return Postgres, nil
+	}
+	return "", fmt.Errorf("invalid database dialect: %q, expected mysql or postgres", name)
+}
+
+func (d Dialect) String() string {
+	return string(d)
+}
+
+// Set parses the dialect of a server database, as a flag.Value.
+func (d *Dialect) Set(value string) error {
+	dialect, err := ParseDialect(value)
+	if err != nil {
+		return err
+	}
+	*d = dialect
+	return nil
+}
+
+// SecondsBetween returns an expression of the seconds elapsed from the start
+// to the end column.
+func (d Dialect) SecondsBetween(start, end string) string {
+	switch d {
+	case SQLite:
+		return fmt.Sprintf("((julianday(%s) - julianday(%s)) * 86400.0)", end, start)
+	case Postgres:
+		return fmt.Sprintf("extract(epoch from (%s - %s))", end, start)
+	}
+	return fmt.Sprintf("(timestampdiff(microsecond, %s, %s) / 1000000.0)", start, end)
+}
+
+// LocalDays returns an integer expression of the whole days from the Unix
+// epoch to the time column shifted by the offset expression, in seconds, e.g.
+// the days of the local date of the column with the offset of a timezone.
+func (d Dialect) LocalDays(column, offset string) string {
+	switch d {
+	case SQLite:
+		return fmt.Sprintf("((cast(strftime('%%s', %s) as integer) + %s) / 86400)", column, offset)
+	case Postgres:
+		return fmt.Sprintf("cast(floor((extract(epoch from %s) + %s) / 86400) as bigint)", column, offset)
+	}
+	return fmt.Sprintf("((floor(unix_timestamp(%s)) + %s) div 86400)", column, offset)
+}
+
+// ReturningID returns the insert query of a row with an id column returning
+// the id on Postgres, which has no LastInsertId, or else the query.
+func (d Dialect) ReturningID(query string) string {
+	if d == Postgres {
+		return query + " returning id"
+	}
+	return query
+}
+
+// Rebind replaces the ? placeholders of the query, outside of quotes, with
+// the numbered ones of Postgres, or else returns the query.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var (
+		rebound = strings.Builder{}
+		quote   rune
+		n       int
+	)
+	rebound.Grow(len(query) + 10)
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(r)
+	}
+	return rebound.String()
+}
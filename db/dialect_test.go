@@ -0,0 +1,73 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDialect(t *testing.T) {
+	for name, expected := range map[string]Dialect{"mysql": MySQL, "postgres": Postgres, " PostgreSQL ": Postgres, "pg": Postgres} {
+		dialect, err := ParseDialect(name)
+		if err != nil {
+			t.Fatalf("unexpected error on to parse %q: %s", name, err.Error())
+		}
+		if dialect != expected {
+			t.Errorf("expected %q for %q, got %q", expected, name, dialect)
+		}
+	}
+	if _, err := ParseDialect("sqlite3"); err == nil {
+		t.Error("expected error on to parse sqlite3, the dialect of the lite version")
+	}
+}
+
+func TestDialect_Rebind(t *testing.T) {
+	query := "select id from activities where description like ? and note = '?' and category_id = ? limit ?"
+
+	rebound := Postgres.Rebind(query)
+	expected := "select id from activities where description like $1 and note = '?' and category_id = $2 limit $3"
+	if rebound != expected {
+		t.Errorf("expected %q, got %q", expected, rebound)
+	}
+	if rebound = MySQL.Rebind(query); rebound != query {
+		t.Errorf("expected the query unchanged on mysql, got %q", rebound)
+	}
+}
+
+func TestDialect_ReturningID(t *testing.T) {
+	query := "insert into clients (name) values (?)"
+	if returning := Postgres.ReturningID(query); returning != query+" returning id" {
+		t.Errorf("unexpected query on postgres: %q", returning)
+	}
+	if returning := SQLite.ReturningID(query); returning != query {
+		t.Errorf("unexpected query on sqlite3: %q", returning)
+	}
+}
+
+func TestConfig_PostgresSource(t *testing.T) {
+	cfg := &Config{Dialect: Postgres, DSN: "postgres://timetrack:secret@db:5432/timetrack?sslmode=disable", Timeout: 10 * time.Second}
+
+	source, err := cfg.postgresSource()
+	if err != nil {
+		t.Fatal("unexpected error on to build source:", err.Error())
+	}
+	for _, expected := range []string{"host='db'", "port='5432'", "user='timetrack'", "dbname='timetrack'", "sslmode='disable'", "connect_timeout=10"} {
+		if !hasField(source, expected) {
+			t.Errorf("expected %q in %q", expected, source)
+		}
+	}
+
+	cfg.DSN = "host=db connect_timeout=3"
+	if source, err = cfg.postgresSource(); err != nil || source != cfg.DSN {
+		t.Errorf("expected the DSN unchanged, got %q, %v", source, err)
+	}
+}
+
+func hasField(source, field string) bool {
+	for _, f := range strings.Fields(source) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	mysqlDuplicateEntry   = 1062
+	mysqlLockWaitTimeout  = 1205
+	mysqlDeadlockDetected = 1213
+
+	postgresUniqueViolation      = "23505"
+	postgresSerializationFailure = "40001"
+	postgresDeadlockDetected     = "40P01"
+)
+
+// IsConflict reports whether err was caused by a concurrent transaction, e.g.
+// a unique constraint violation, a deadlock or a busy database, so that the
+// operation can be retried.
+func IsConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry, mysqlLockWaitTimeout, mysqlDeadlockDetected:
+			return true
+		}
+		return false
+	}
+
+	var postgresErr *pq.Error
+	if errors.As(err, &postgresErr) {
+		switch postgresErr.Code {
+		case postgresUniqueViolation, postgresSerializationFailure, postgresDeadlockDetected:
+			return true
+		}
+		return false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch {
+		case sqliteErr.Code == sqlite3.ErrBusy, sqliteErr.Code == sqlite3.ErrLocked:
+			return true
+		case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUniqueViolation reports whether err was caused by a row violating a
+// unique constraint, e.g. a name already taken by another row.
+func IsUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	var postgresErr *pq.Error
+	if errors.As(err, &postgresErr) {
+		return postgresErr.Code == postgresUniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	return false
+}
@@ -4,8 +4,7 @@ import (
 	"github.com/ungame/timetrack/ioext"
 	"log"
 	"os"
-	"path"
-	"runtime"
+	"path/filepath"
 )
 
 const (
@@ -17,37 +16,28 @@ type FileStorage interface {
 	Create() string
 }
 
-type defaultFileStorage struct{}
-
-func DefaultFileStorage() FileStorage {
-	return &defaultFileStorage{}
+type fileStorage struct {
+	path string
 }
 
-func (d *defaultFileStorage) Create() string {
-	var (
-		currentPath  = getCurrentPath()
-		fullDirPath  = currentPath + "/" + defaultDir
-		fullFilePath = fullDirPath + "/" + defaultFile
-	)
+// NewFileStorage stores the database at the given file path, creating the
+// file and its directory when needed.
+func NewFileStorage(path string) FileStorage {
+	return &fileStorage{path: path}
+}
 
-	if _, err := os.Stat(fullDirPath); os.IsNotExist(err) {
-		if err = os.Mkdir(fullDirPath, os.ModePerm); err != nil {
-			log.Panicln("error on create storage:", err)
-		}
+func (f *fileStorage) Create() string {
+	if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+		log.Panicln("error on create storage:", err)
 	}
 
-	if _, err := os.Stat(fullFilePath); os.IsNotExist(err) {
-		if file, err := os.Create(fullFilePath); err == nil {
+	if _, err := os.Stat(f.path); os.IsNotExist(err) {
+		if file, err := os.Create(f.path); err == nil {
 			ioext.Close(file)
 		} else {
 			log.Println("error on create file:", err.Error())
 		}
 	}
 
-	return fullFilePath
-}
-
-func getCurrentPath() string {
-	_, file, _, _ := runtime.Caller(0)
-	return path.Dir(file)
+	return f.path
 }
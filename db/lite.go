@@ -6,8 +6,12 @@ import (
 	"log"
 )
 
+// liteOptions makes transactions take the write lock as soon as they begin
+// and wait for concurrent writers instead of failing with SQLITE_BUSY.
+const liteOptions = "?_txlock=immediate&_busy_timeout=5000"
+
 func Lite(fileStorage FileStorage, migrations ...Migration) *sql.DB {
-	conn, err := sql.Open("sqlite3", fileStorage.Create())
+	conn, err := sql.Open("sqlite3", fileStorage.Create()+liteOptions)
 	if err != nil {
 		log.Panicln(err.Error())
 	}
@@ -1,128 +1,390 @@
 package db
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/ungame/timetrack/ioext"
-	"io"
+	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-const sqlFile = "infra/timetrack/sql/init.sql"
-
+// Migration is a step run on a database when it is opened, such as applying
+// the pending versions of the schema.
 type Migration interface {
 	Up(conn *sql.DB)
 }
 
-type migration struct {
-	name  string
-	query string
+const (
+	migrationsDir     = "migrations"
+	migrationsTable   = "schema_migrations"
+	legacyTable       = "categories"
+	recordQuery       = "insert into " + migrationsTable + " (version, name, checksum, applied_at) values (?, ?, ?, ?)"
+	deleteRecordQuery = "delete from " + migrationsTable + " where version = ?"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationFile matches the files of a version, e.g. 0001_init.up.sql and
+// 0001_init.down.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Version is a numbered change of the schema, read from the up and down
+// files of a dialect in the migrations directory.
+type Version struct {
+	Number   int
+	Name     string
+	Checksum string
+	up       string
+	down     string
 }
 
-func NewMigration(name, query string) Migration {
-	return &migration{
-		name:  name,
-		query: query,
-	}
+func (v *Version) String() string {
+	return fmt.Sprintf("%04d_%s", v.Number, v.Name)
 }
 
-func (m *migration) Up(conn *sql.DB) {
-	m.createTable(conn)
+// VersionStatus is a version of the schema as seen in a database. Applied
+// versions whose file changed are modified, and the ones without a file are
+// missing.
+type VersionStatus struct {
+	Number    int
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+const (
+	StatePending  = "pending"
+	StateApplied  = "applied"
+	StateModified = "modified"
+	StateMissing  = "missing"
+)
 
-	if m.hasMigration(conn) {
-		return
+// Versions returns the versions of the dialect in order.
+func Versions(dialect Dialect) ([]*Version, error) {
+	dir := path.Join(migrationsDir, string(dialect))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
 	}
 
-	m.migrate(conn)
-}
+	byNumber := make(map[int]*Version, len(entries)/2)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file: %s", entry.Name())
+		}
+		number, _ := strconv.Atoi(match[1])
+		version, ok := byNumber[number]
+		if !ok {
+			version = &Version{Number: number, Name: match[2]}
+			byNumber[number] = version
+		}
+		if version.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", number, version.Name, match[2])
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			version.up = string(content)
+		} else {
+			version.down = string(content)
+		}
+	}
 
-func (m *migration) createTable(conn *sql.DB) {
-	query := "CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY)"
-	if _, err := conn.Exec(query); err != nil {
-		log.Panicln(err.Error())
+	versions := make([]*Version, 0, len(byNumber))
+	for _, version := range byNumber {
+		if version.up == "" || version.down == "" {
+			return nil, fmt.Errorf("migration %s needs an up and a down file", version)
+		}
+		sum := sha256.Sum256([]byte(version.up))
+		version.Checksum = hex.EncodeToString(sum[:])
+		versions = append(versions, version)
 	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Number < versions[j].Number
+	})
+	return versions, nil
 }
 
-func (m *migration) hasMigration(conn *sql.DB) bool {
-	query := "SELECT COUNT(*) FROM migrations WHERE name='" + m.name + "'"
-	var count int64
-	err := conn.QueryRow(query).Scan(&count)
-	if err == sql.ErrNoRows {
-		return false
+// Migrator applies and reverts the versions of a dialect, recording the
+// applied ones with their checksum in the schema_migrations table. Each
+// version runs in a transaction, though MySQL commits DDL statements as they
+// run.
+type Migrator struct {
+	conn     *sql.DB
+	dialect  Dialect
+	versions []*Version
+}
+
+func NewMigrator(conn *sql.DB, dialect Dialect) (*Migrator, error) {
+	versions, err := Versions(dialect)
+	if err != nil {
+		return nil, err
 	}
+	return &Migrator{conn: conn, dialect: dialect, versions: versions}, nil
+}
+
+// Up applies the pending versions in order and returns them. It fails
+// without applying any version if an applied one changed or is unknown.
+func (m *Migrator) Up(ctx context.Context) ([]*Version, error) {
+	applied, err := m.applied(ctx)
 	if err != nil {
-		log.Panicln(err)
+		return nil, err
+	}
+
+	if len(applied) == 0 && len(m.versions) > 0 {
+		baseline, err := m.hasTable(ctx, legacyTable)
+		if err != nil {
+			return nil, err
+		}
+		if baseline {
+			// databases created before versioned migrations have the
+			// schema of the first version
+			first := m.versions[0]
+			log.Println("baseline migration:", first)
+			if err = m.run(ctx, "", recordQuery, first.Number, first.Name, first.Checksum, time.Now().UTC()); err != nil {
+				return nil, err
+			}
+			applied[first.Number] = &appliedVersion{name: first.Name, checksum: first.Checksum}
+		}
+	}
+
+	known := make(map[int]*Version, len(m.versions))
+	for _, version := range m.versions {
+		known[version.Number] = version
+		if a, ok := applied[version.Number]; ok && a.checksum != version.Checksum {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, version)
+		}
+	}
+	for number := range applied {
+		if known[number] == nil {
+			return nil, fmt.Errorf("%w: %d is applied but has no file", ErrUnknownVersion, number)
+		}
+	}
+
+	ran := make([]*Version, 0, len(m.versions))
+	for _, version := range m.versions {
+		if _, ok := applied[version.Number]; ok {
+			continue
+		}
+		err = m.run(ctx, version.up, recordQuery, version.Number, version.Name, version.Checksum, time.Now().UTC())
+		if err != nil {
+			return ran, fmt.Errorf("migration %s: %w", version, err)
+		}
+		ran = append(ran, version)
 	}
-	return count > 0
+	return ran, nil
 }
 
-func (m *migration) migrate(conn *sql.DB) {
-	log.Println("initializing transaction...")
-	tx, err := conn.Begin()
-	result, err := tx.Exec(m.query)
+// Down reverts up to steps applied versions, from the last one, and returns
+// them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Version, error) {
+	applied, err := m.applied(ctx)
 	if err != nil {
-		log.Panicln(err)
+		return nil, err
+	}
+
+	ran := make([]*Version, 0, steps)
+	for i := len(m.versions) - 1; i >= 0 && len(ran) < steps; i-- {
+		version := m.versions[i]
+		if _, ok := applied[version.Number]; !ok {
+			continue
+		}
+		err = m.run(ctx, version.down, deleteRecordQuery, version.Number)
+		if err != nil {
+			return ran, fmt.Errorf("migration %s: %w", version, err)
+		}
+		ran = append(ran, version)
 	}
+	return ran, nil
+}
 
-	rows, err := result.RowsAffected()
+// Status returns the state of every version known by the files or applied
+// to the database, in order.
+func (m *Migrator) Status(ctx context.Context) ([]*VersionStatus, error) {
+	applied, err := m.applied(ctx)
 	if err != nil {
-		log.Println("error on get affected rows:", err.Error())
-	} else {
-		log.Println("query executed. rows affected:", rows)
+		return nil, err
 	}
 
-	query := "INSERT INTO migrations (name) VALUES ('" + m.name + "')"
-	result, err = tx.Exec(query)
+	statuses := make([]*VersionStatus, 0, len(m.versions))
+	for _, version := range m.versions {
+		status := &VersionStatus{Number: version.Number, Name: version.Name, State: StatePending}
+		if a, ok := applied[version.Number]; ok {
+			status.State, status.AppliedAt = StateApplied, a.appliedAt
+			if a.checksum != version.Checksum {
+				status.State = StateModified
+			}
+			delete(applied, version.Number)
+		}
+		statuses = append(statuses, status)
+	}
+	for number, a := range applied {
+		statuses = append(statuses, &VersionStatus{Number: number, Name: a.name, State: StateMissing, AppliedAt: a.appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Number < statuses[j].Number
+	})
+	return statuses, nil
+}
+
+type appliedVersion struct {
+	name      string
+	checksum  string
+	appliedAt *time.Time
+}
+
+// applied returns the applied versions by number, creating the table of
+// applied versions on first use.
+func (m *Migrator) applied(ctx context.Context) (map[int]*appliedVersion, error) {
+	query := "CREATE TABLE IF NOT EXISTS " + migrationsTable + ` (
+    version INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMP NULL
+)`
+	if _, err := m.conn.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.conn.QueryContext(ctx, "select version, name, checksum, applied_at from "+migrationsTable)
 	if err != nil {
-		log.Println("error on exec second query:", err.Error())
-		if err = tx.Rollback(); err != nil {
-			log.Panicln(err)
-		} else {
-			log.Println("rollback successfully!")
+		return nil, err
+	}
+	defer ioext.Close(rows)
+
+	applied := make(map[int]*appliedVersion)
+	for rows.Next() {
+		var (
+			number    int
+			a         = new(appliedVersion)
+			appliedAt sql.NullTime
+		)
+		if err = rows.Scan(&number, &a.name, &a.checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		if appliedAt.Valid {
+			a.appliedAt = &appliedAt.Time
 		}
+		applied[number] = a
 	}
+	return applied, rows.Err()
+}
 
-	rows, err = result.RowsAffected()
+// run runs the statements of the script and then the record query with its
+// arguments in a transaction.
+func (m *Migrator) run(ctx context.Context, script, record string, args ...any) error {
+	tx, err := m.conn.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("error on get affected rows:", err.Error())
-	} else {
-		log.Println("migrations updated. rows affected:", rows)
+		return err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	if err = tx.Commit(); err != nil {
-		log.Panicln(err.Error())
+	for _, statement := range splitStatements(script) {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
 	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	log.Println("transaction completed successfully.")
+func (m *Migrator) hasTable(ctx context.Context, name string) (bool, error) {
+	query := "select count(*) from information_schema.tables where table_schema = database() and table_name = ?"
+	switch m.dialect {
+	case SQLite:
+		query = "select count(*) from sqlite_master where type = 'table' and name = ?"
+	case Postgres:
+		query = "select count(*) from information_schema.tables where table_schema = current_schema() and table_name = ?"
+	}
+	var count int64
+	err := m.conn.QueryRowContext(ctx, query, name).Scan(&count)
+	return count > 0, err
 }
 
-func GetSqliteSeed() (string, string) {
+// splitStatements splits a script into its statements, which end with a
+// semicolon at the end of a line, except within BEGIN and END of triggers.
+// Comments alone are not statements.
+func splitStatements(script string) []string {
 	var (
-		rootPath = filepath.Join(getCurrentPath(), "../")
-		fullPath = rootPath + "/" + sqlFile
+		statements []string
+		statement  strings.Builder
+		code       bool
+		block      bool
 	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" && statement.Len() == 0 {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			code = true
+		}
+
+		upper := strings.ToUpper(trimmed)
+		switch {
+		case strings.HasSuffix(upper, "BEGIN"):
+			block = true
+		case block && upper == "END;":
+			block = false
+			fallthrough
+		case !block && strings.HasSuffix(trimmed, ";"):
+			if code {
+				statements = append(statements, strings.TrimSpace(statement.String()))
+			}
+			statement.Reset()
+			code = false
+		}
+	}
+	if code {
+		statements = append(statements, strings.TrimSpace(statement.String()))
+	}
+	return statements
+}
+
+type versionsMigration struct {
+	dialect Dialect
+}
+
+// Migrations applies the pending versions of the dialect when a database is
+// opened, logging the ones that ran.
+func Migrations(dialect Dialect) Migration {
+	return &versionsMigration{dialect: dialect}
+}
 
-	file, err := os.Open(fullPath)
+func (v *versionsMigration) Up(conn *sql.DB) {
+	migrator, err := NewMigrator(conn, v.dialect)
 	if err != nil {
 		log.Panicln(err)
 	}
-	defer ioext.Close(file)
-
-	content, err := io.ReadAll(file)
+	ran, err := migrator.Up(context.Background())
+	for _, version := range ran {
+		log.Println("migration applied:", version)
+	}
 	if err != nil {
 		log.Panicln(err)
 	}
-
-	return fullPath, convertToSqLiteQuery(string(content))
-}
-
-func convertToSqLiteQuery(query string) string {
-	query = strings.ReplaceAll(query, "BIGINT PRIMARY KEY", "INTEGER PRIMARY KEY")
-	query = strings.ReplaceAll(query, "INT PRIMARY KEY", "INTEGER PRIMARY KEY")
-	query = strings.ReplaceAll(query, "AUTO_INCREMENT", "AUTOINCREMENT")
-	query = strings.ReplaceAll(query, "ENGINE = INNODB", "")
-	query = strings.ReplaceAll(query, "DEFAULT CHARSET = UTF8", "")
-	return query
 }
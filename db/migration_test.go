@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/ungame/timetrack/ioext"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		conn     = newTestConn(t)
+		migrator = newTestMigrator(t, conn)
+	)
+
+	ran, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(migrator.versions) {
+		t.Fatalf("unexpected applied versions: expected=%d, got=%d", len(migrator.versions), len(ran))
+	}
+	if ran, err = migrator.Up(ctx); err != nil || len(ran) != 0 {
+		t.Fatalf("unexpected second up: versions=%v, err=%v", ran, err)
+	}
+	assertStates(t, migrator, StateApplied)
+
+	last := migrator.versions[len(migrator.versions)-1]
+	ran, err = migrator.Down(ctx, len(migrator.versions)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(migrator.versions) || ran[0] != last {
+		t.Fatalf("unexpected reverted versions: %v", ran)
+	}
+	assertStates(t, migrator, StatePending)
+	if hasTable(t, migrator, "activities") {
+		t.Error("expected activities to be dropped")
+	}
+}
+
+func TestMigrator_UpChecksumMismatch(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		conn     = newTestConn(t)
+		migrator = newTestMigrator(t, conn)
+	)
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("update schema_migrations set checksum = 'changed' where version = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("unexpected error: expected=%v, got=%v", ErrChecksumMismatch, err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].State != StateModified {
+		t.Errorf("unexpected state of version 1: expected=%s, got=%s", StateModified, statuses[0].State)
+	}
+}
+
+func TestMigrator_UpBaseline(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		conn     = newTestConn(t)
+		migrator = newTestMigrator(t, conn)
+	)
+
+	// the schema of the first version, as created before versioned
+	// migrations, whose seed fails if the version runs again
+	first := migrator.versions[0]
+	for _, statement := range splitStatements(first.up) {
+		if _, err := conn.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ran, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range ran {
+		if version.Number == first.Number {
+			t.Errorf("unexpected run of baseline version %s", first)
+		}
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].State != StateApplied {
+		t.Errorf("unexpected state of baseline version: expected=%s, got=%s", StateApplied, statuses[0].State)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- tables
+CREATE TABLE a (id INT);
+
+CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN
+    INSERT INTO b (id) VALUES (new.id);
+    INSERT INTO c (id) VALUES (new.id);
+END;
+
+INSERT INTO a (id) VALUES
+(1),
+(2)
+;
+-- done
+`
+	expected := []string{
+		"-- tables\nCREATE TABLE a (id INT);",
+		"CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN\n    INSERT INTO b (id) VALUES (new.id);\n    INSERT INTO c (id) VALUES (new.id);\nEND;",
+		"INSERT INTO a (id) VALUES\n(1),\n(2)\n;",
+	}
+
+	statements := splitStatements(script)
+	if len(statements) != len(expected) {
+		t.Fatalf("unexpected statements: expected=%d, got=%d: %q", len(expected), len(statements), statements)
+	}
+	for i := range expected {
+		if statements[i] != expected[i] {
+			t.Errorf("unexpected statement %d: expected=%q, got=%q", i, expected[i], statements[i])
+		}
+	}
+}
+
+func newTestConn(t *testing.T) *sql.DB {
+	t.Helper()
+	conn := Lite(NewFileStorage(filepath.Join(t.TempDir(), "test.sqlite")))
+	t.Cleanup(func() { ioext.Close(conn) })
+	return conn
+}
+
+func newTestMigrator(t *testing.T, conn *sql.DB) *Migrator {
+	t.Helper()
+	migrator, err := NewMigrator(conn, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator
+}
+
+func assertStates(t *testing.T, migrator *Migrator, expected string) {
+	t.Helper()
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.State != expected {
+			t.Errorf("unexpected state of version %d: expected=%s, got=%s", status.Number, expected, status.State)
+		}
+	}
+}
+
+func hasTable(t *testing.T, migrator *Migrator, name string) bool {
+	t.Helper()
+	ok, err := migrator.hasTable(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
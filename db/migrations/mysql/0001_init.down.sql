@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS activity_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS activity_intervals;
+DROP TABLE IF EXISTS activity_changes;
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS categories;
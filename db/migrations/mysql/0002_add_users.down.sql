@@ -0,0 +1,11 @@
+-- only the running activity of the default user keeps its flag
+UPDATE activities SET running = NULL WHERE user_id <> 1;
+
+ALTER TABLE activities DROP FOREIGN KEY activities_user_id_fk;
+
+ALTER TABLE activities
+    DROP INDEX activities_user_id_started_at_idx,
+    DROP COLUMN user_id,
+    MODIFY running TINYINT NULL;
+
+DROP TABLE IF EXISTS users;
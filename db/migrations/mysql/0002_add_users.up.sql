@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)
+ENGINE = INNODB
+DEFAULT CHARSET = UTF8;
+
+-- activities recorded before users existed belong to the default user
+INSERT INTO users (id, name) VALUES (1, 'default');
+
+-- the running flag holds the id of the user of the running activity, so its
+-- unique key allows one running activity per user
+ALTER TABLE activities
+    ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1,
+    MODIFY running BIGINT NULL,
+    ADD CONSTRAINT activities_user_id_fk
+    FOREIGN KEY (user_id)
+    REFERENCES users(id),
+    ADD INDEX activities_user_id_started_at_idx (user_id, started_at);
@@ -0,0 +1,16 @@
+-- API tokens of the users, stored as the SHA-256 hash of their secret
+CREATE TABLE IF NOT EXISTS tokens (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL,
+    INDEX tokens_user_id_idx (user_id),
+    CONSTRAINT tokens_user_id_fk
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+)
+ENGINE = INNODB
+DEFAULT CHARSET = UTF8;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- activities recorded before users existed belong to the default user
+INSERT INTO users (id, name) VALUES (1, 'default');
+
+SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT max(id) FROM users));
+
+-- the running flag holds the id of the user of the running activity, so its
+-- unique key allows one running activity per user
+ALTER TABLE activities
+    ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1
+    CONSTRAINT activities_user_id_fk
+    REFERENCES users(id),
+    ALTER COLUMN running TYPE BIGINT;
+
+CREATE INDEX activities_user_id_started_at_idx ON activities (user_id, started_at);
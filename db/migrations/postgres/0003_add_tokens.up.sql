@@ -0,0 +1,15 @@
+-- API tokens of the users, stored as the SHA-256 hash of their secret
+CREATE TABLE IF NOT EXISTS tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(10) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ NULL,
+    CONSTRAINT tokens_user_id_fk
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS tokens_user_id_idx ON tokens (user_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS activities_search;
+DROP TABLE IF EXISTS activity_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS activity_intervals;
+DROP TABLE IF EXISTS activity_changes;
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,101 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    hourly_rate BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO categories (id, name, description) VALUES
+(1, 'meeting', 'daily, 1:1, refinement, retro'),
+(2, 'coding', 'features, bugs, tests'),
+(3, 'review', 'pull requests'),
+(4, 'pm', 'private messages'),
+(5, 'task', '')
+;
+
+CREATE TABLE IF NOT EXISTS clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INT NULL,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT projects_client_id_fk
+    FOREIGN KEY (client_id)
+    REFERENCES clients(id)
+);
+
+CREATE TABLE IF NOT EXISTS activities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category_id INT NOT NULL,
+    description TEXT NOT NULL,
+    status CHAR(1) DEFAULT '1',
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    running TINYINT NULL,
+    project_id INT NULL,
+    billable TINYINT NOT NULL DEFAULT 0,
+    hourly_rate BIGINT NULL,
+    CONSTRAINT activities_category_id_fk
+    FOREIGN KEY (category_id)
+    REFERENCES categories(id),
+    CONSTRAINT activities_project_id_fk
+    FOREIGN KEY (project_id)
+    REFERENCES projects(id),
+    CONSTRAINT activities_running_uk
+    UNIQUE (running)
+);
+
+CREATE TABLE IF NOT EXISTS activity_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    activity_id BIGINT NOT NULL,
+    field VARCHAR(20) NOT NULL,
+    old_value TEXT NULL,
+    new_value TEXT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT activity_changes_activity_id_fk
+    FOREIGN KEY (activity_id)
+    REFERENCES activities(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS activity_intervals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    activity_id BIGINT NOT NULL,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    CONSTRAINT activity_intervals_activity_id_fk
+    FOREIGN KEY (activity_id)
+    REFERENCES activities(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS activity_tags (
+    activity_id BIGINT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (activity_id, tag_id),
+    CONSTRAINT activity_tags_activity_id_fk
+    FOREIGN KEY (activity_id)
+    REFERENCES activities(id)
+    ON DELETE CASCADE,
+    CONSTRAINT activity_tags_tag_id_fk
+    FOREIGN KEY (tag_id)
+    REFERENCES tags(id)
+);
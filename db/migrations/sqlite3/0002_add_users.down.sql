@@ -0,0 +1,8 @@
+-- only the running activity of the default user keeps its flag
+UPDATE activities SET running = NULL WHERE user_id <> 1;
+
+DROP INDEX IF EXISTS activities_user_id_started_at_idx;
+
+ALTER TABLE activities DROP COLUMN user_id;
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- activities recorded before users existed belong to the default user
+INSERT INTO users (id, name) VALUES (1, 'default');
+
+-- the running flag holds the id of the user of the running activity, so its
+-- unique key allows one running activity per user. The column has no foreign
+-- key, which SQLite could not drop on down.
+ALTER TABLE activities ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS activities_user_id_started_at_idx ON activities (user_id, started_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tokens_user_id_idx;
+
+DROP TABLE IF EXISTS tokens;
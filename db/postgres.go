@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// postgresSource returns the DSN in the key=value form of lib/pq, also given
+// as a postgres:// URL, with Timeout to connect unless the DSN sets one.
+func (c *Config) postgresSource() (string, error) {
+	source := c.DSN
+	if source == "" {
+		source = defaultPostgresSource
+	}
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		var err error
+		if source, err = pq.ParseURL(source); err != nil {
+			return "", err
+		}
+	}
+	if !strings.Contains(source, "connect_timeout=") && c.Timeout > 0 {
+		source = fmt.Sprintf("%s connect_timeout=%d", source, int(c.Timeout.Seconds()+0.5))
+	}
+	return strings.TrimSpace(source), nil
+}
+
+// openPostgres opens a Postgres connection whose queries are rebound from
+// the ? placeholders of the repositories to the $1, $2... of Postgres.
+func openPostgres(source string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(source)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&rebindConnector{connector: connector}), nil
+}
+
+type rebindConnector struct {
+	connector driver.Connector
+}
+
+func (c *rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rebindConn{Conn: conn}, nil
+}
+
+func (c *rebindConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// rebindConn rebinds the queries it runs or prepares, and leaves the rest to
+// the connection of the driver.
+type rebindConn struct {
+	driver.Conn
+}
+
+func (c *rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(Postgres.Rebind(query))
+}
+
+func (c *rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, Postgres.Rebind(query))
+	}
+	return c.Prepare(query)
+}
+
+func (c *rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, Postgres.Rebind(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, Postgres.Rebind(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *rebindConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+)
+
+// SearchTable is the FTS5 index of activity descriptions in lite mode.
+const SearchTable = "activities_search"
+
+const searchQuery = `
+CREATE VIRTUAL TABLE IF NOT EXISTS activities_search USING fts5(description, content='activities', content_rowid='id');
+
+CREATE TRIGGER IF NOT EXISTS activities_search_ai AFTER INSERT ON activities BEGIN
+    INSERT INTO activities_search (rowid, description) VALUES (new.id, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS activities_search_ad AFTER DELETE ON activities BEGIN
+    INSERT INTO activities_search (activities_search, rowid, description) VALUES ('delete', old.id, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS activities_search_au AFTER UPDATE OF description ON activities BEGIN
+    INSERT INTO activities_search (activities_search, rowid, description) VALUES ('delete', old.id, old.description);
+    INSERT INTO activities_search (rowid, description) VALUES (new.id, new.description);
+END;
+
+INSERT INTO activities_search (activities_search) VALUES ('rebuild');
+`
+
+type searchMigration struct{}
+
+// NewSearchMigration creates the full-text index of activity descriptions in
+// lite mode, kept up to date by triggers, unless it exists. It is not a
+// version of the schema because FTS5 is only compiled into the SQLite driver
+// with the sqlite_fts5 build tag, so the migration is skipped without it and
+// searches fall back to LIKE.
+func NewSearchMigration() Migration {
+	return &searchMigration{}
+}
+
+func (m *searchMigration) Up(conn *sql.DB) {
+	if !HasFTS5(conn) {
+		// the triggers of the index would fail every write to activities
+		if HasSearchIndex(conn) {
+			log.Panicln("database has a full-text index: build with -tags sqlite_fts5")
+		}
+		log.Println("full-text search disabled: build with -tags sqlite_fts5 to enable it")
+		return
+	}
+	if HasSearchIndex(conn) {
+		return
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		log.Panicln(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, statement := range splitStatements(searchQuery) {
+		if _, err = tx.Exec(statement); err != nil {
+			log.Panicln(err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		log.Panicln(err)
+	}
+	log.Println("full-text index created:", SearchTable)
+}
+
+// HasFTS5 reports whether the SQLite driver was compiled with FTS5.
+func HasFTS5(conn *sql.DB) bool {
+	var used bool
+	if err := conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used); err != nil {
+		log.Println("unable to check FTS5:", err.Error())
+		return false
+	}
+	return used
+}
+
+// HasSearchIndex reports whether the lite database has the SearchTable.
+func HasSearchIndex(conn *sql.DB) bool {
+	var count int64
+	err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = ?", SearchTable).Scan(&count)
+	if err != nil {
+		log.Println("unable to check search index:", err.Error())
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,55 @@
+package httpext
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// formulaPrefixes are the first characters that make spreadsheets evaluate a
+// cell as a formula, including the tab and carriage return that some of them
+// skip before the formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// WriteCSV writes the records as an attachment named filename, with the cells
+// that spreadsheets would evaluate as formulas escaped by EscapeCSV.
+func WriteCSV(w http.ResponseWriter, status int, filename string, records [][]string) {
+	w.Header().Set(HeaderContentType, MimeCSV)
+	w.Header().Set(HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(status)
+	writer := csv.NewWriter(w)
+	for _, record := range records {
+		escaped := make([]string, len(record))
+		for i, cell := range record {
+			escaped[i] = EscapeCSV(cell)
+		}
+		if err := writer.Write(escaped); err != nil {
+			log.Println("write csv failed with error:", err.Error())
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Println("write csv failed with error:", err.Error())
+	}
+}
+
+// EscapeCSV prefixes the cell with a quote if it starts with =, +, -, @, a tab
+// or a carriage return, so that spreadsheets show it as text instead of
+// evaluating it as a formula.
+func EscapeCSV(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// UnescapeCSV removes the quote EscapeCSV prefixes cells with.
+func UnescapeCSV(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
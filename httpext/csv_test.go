@@ -0,0 +1,31 @@
+package httpext
+
+import "testing"
+
+func TestEscapeCSV(t *testing.T) {
+	tests := []struct {
+		cell     string
+		expected string
+	}{
+		{cell: "", expected: ""},
+		{cell: "coding", expected: "coding"},
+		{cell: "a=b", expected: "a=b"},
+		{cell: "'quoted", expected: "'quoted"},
+		{cell: "=1+1", expected: "'=1+1"},
+		{cell: "+1", expected: "'+1"},
+		{cell: "-1", expected: "'-1"},
+		{cell: "@SUM(A1:A2)", expected: "'@SUM(A1:A2)"},
+		{cell: "\t=1+1", expected: "'\t=1+1"},
+		{cell: "\r=1+1", expected: "'\r=1+1"},
+	}
+
+	for _, test := range tests {
+		escaped := EscapeCSV(test.cell)
+		if escaped != test.expected {
+			t.Errorf("unexpected escape of %q: expected=%q, got=%q", test.cell, test.expected, escaped)
+		}
+		if unescaped := UnescapeCSV(escaped); unescaped != test.cell {
+			t.Errorf("unexpected unescape of %q: expected=%q, got=%q", escaped, test.cell, unescaped)
+		}
+	}
+}
@@ -11,8 +11,19 @@ const (
 	HeaderContentType = "Content-Type"
 	HeaderLocation    = "Location"
 	HeaderEntity      = "Entity"
+	HeaderTimezone    = "X-Timezone"
+	HeaderUser        = "X-User"
 
-	MimeJSON = "application/json"
+	HeaderAuthorization   = "Authorization"
+	HeaderWWWAuthenticate = "WWW-Authenticate"
+
+	HeaderContentDisposition = "Content-Disposition"
+
+	MimeJSON    = "application/json"
+	MimeProblem = "application/problem+json"
+	MimeNDJSON  = "application/x-ndjson"
+	MimeCSV     = "text/csv"
+	MimeICS     = "text/calendar"
 )
 
 func WriteJson(w http.ResponseWriter, status int, data any) {
@@ -23,6 +34,12 @@ func WriteJson(w http.ResponseWriter, status int, data any) {
 	}
 }
 
-func WriteError(w http.ResponseWriter, status int, err error) {
-	WriteJson(w, status, types.NewError(err))
+// WriteProblem writes the problem as an application/problem+json response
+// with its status.
+func WriteProblem(w http.ResponseWriter, problem *types.Problem) {
+	w.Header().Set(HeaderContentType, MimeProblem)
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Println("write problem failed with error:", err.Error())
+	}
 }
@@ -0,0 +1,34 @@
+package httpext
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const HeaderLink = "Link"
+
+// SetNextLink sets the Link header to the uri of the next page.
+func SetNextLink(w http.ResponseWriter, uri string) {
+	w.Header().Set(HeaderLink, fmt.Sprintf(`<%s>; rel="next"`, uri))
+}
+
+// NextLink returns the uri of the next page in the Link header, or an empty
+// string when there is none.
+func NextLink(header http.Header) string {
+	for _, value := range header.Values(HeaderLink) {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			uri := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(uri, "<") || !strings.HasSuffix(uri, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if strings.ReplaceAll(strings.TrimSpace(param), `"`, "") == "rel=next" {
+					return strings.TrimSuffix(strings.TrimPrefix(uri, "<"), ">")
+				}
+			}
+		}
+	}
+	return ""
+}
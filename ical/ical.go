@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeFormat = "20060102T150405Z"
+
+	// maxLineLength is the length in octets after which content lines are
+	// folded, as RFC 5545 recommends.
+	maxLineLength = 75
+)
+
+// Calendar is a VCALENDAR of events, whose times are written in UTC.
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []*Event
+}
+
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Categories  []string
+}
+
+// WriteTo writes the calendar in the iCalendar format.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	lw := &lineWriter{w: bufio.NewWriter(w)}
+
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", c.ProdID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, event := range c.Events {
+		lw.line("BEGIN", "VEVENT")
+		lw.line("UID", event.UID)
+		lw.line("DTSTAMP", formatTime(event.Stamp))
+		lw.line("DTSTART", formatTime(event.Start))
+		lw.line("DTEND", formatTime(event.End))
+		lw.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			lw.line("DESCRIPTION", escape(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, 0, len(event.Categories))
+			for _, category := range event.Categories {
+				categories = append(categories, escape(category))
+			}
+			lw.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		lw.line("END", "VEVENT")
+	}
+	lw.line("END", "VCALENDAR")
+
+	if lw.err == nil {
+		lw.err = lw.w.Flush()
+	}
+	return lw.n, lw.err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escape escapes the characters with a meaning in TEXT values.
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// lineWriter writes content lines ended by CRLF, folding the ones longer than
+// maxLineLength octets without splitting UTF-8 characters, and keeps the
+// first error.
+type lineWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (lw *lineWriter) line(name, value string) {
+	line := name + ":" + value
+	for len(line) > maxLineLength {
+		cut := maxLineLength
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		lw.write(line[:cut] + "\r\n")
+		// continuation lines start with a space, which counts in their length
+		line = " " + line[cut:]
+	}
+	lw.write(line + "\r\n")
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	n, err := lw.w.WriteString(s)
+	lw.n += int64(n)
+	lw.err = err
+}
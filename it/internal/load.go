@@ -2,13 +2,16 @@ package internal
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/ungame/timetrack/app/models"
 	"github.com/ungame/timetrack/app/repository"
+	"github.com/ungame/timetrack/config"
 	"github.com/ungame/timetrack/db"
 	"github.com/ungame/timetrack/ioext"
 	"github.com/ungame/timetrack/timeext"
+	"github.com/ungame/timetrack/types"
 	"log"
 	"math/rand"
 	"sync"
@@ -18,22 +21,32 @@ import (
 
 const defaultTimeout = time.Second * 3
 
-func Load(startTime time.Time) {
+func Load(cfg *config.Config, startTime time.Time) {
 	log.Println("Start loading from: ", startTime.Format(timeext.DateTimeFormat))
 	defer log.Println("Stopped.")
 
-	ctx := context.Background()
-	conn := db.Lite(db.DefaultFileStorage(), db.NewMigration(db.GetSqliteSeed()))
+	var (
+		ctx     = context.Background()
+		conn    *sql.DB
+		dialect = cfg.Database.Dialect
+	)
+	if cfg.Lite {
+		conn = db.Lite(db.NewFileStorage(cfg.Database.Path), db.Migrations(db.SQLite))
+		dialect = db.SQLite
+	} else {
+		conn = db.New(cfg.Database)
+		db.Migrations(dialect).Up(conn)
+	}
 	defer ioext.Close(conn)
 
-	catRepo := repository.NewCategoriesRepository(conn)
+	catRepo := repository.NewCategoriesRepository(conn, dialect)
 
 	categories, err := catRepo.GetAll(ctx)
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	actRepo := repository.NewActivitiesRepository(conn)
+	actRepo := repository.NewActivitiesRepository(conn, dialect)
 	defer actRepo.Close()
 
 	err = actRepo.Truncate(ctx)
@@ -45,92 +58,67 @@ func Load(startTime time.Time) {
 	yesterday := time.Now().AddDate(0, 0, -1)
 	end := timeext.GetEndOfDayFrom(yesterday, yesterday.Location())
 
-	finishCh := make(chan *models.Activity, 10)
 	semaphore := make(chan struct{}, 10)
-	startedCounter := 0
+	counter := NewCounter()
+	index := 0
 
 	wg := sync.WaitGroup{}
 
-	wg.Add(1)
-	go func(finish <-chan *models.Activity) {
-		defer wg.Done()
-
-		log.Println("Starting loop to finish activities.")
-
-		finishedCounter := 0
-
-		for {
-			select {
-			case activity := <-finish:
-				log.Println("[*] Received Activity:", activity.ID)
-				activity.Status = models.Finished
-				finishedAt := activity.StartedAt.Time.Add(time.Hour)
-
-				activity.SetUpdatedAt(finishedAt)
-				activity.SetFinishedAt(finishedAt)
-
-				timeoutCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
-
-				_, err = actRepo.Update(timeoutCtx, activity)
-				if err != nil {
-					log.Panicln(err)
-				}
-
-				log.Println("--> Activity finished:", activity.ID)
-				finishedCounter++
-				cancel()
-
-			default:
-				if finishedCounter == startedCounter {
-					return
-				}
-			}
-		}
-	}(finishCh)
-
 	for startTime.Before(end) {
-		activity := start(categories, startTime)
+		activity := finished(categories, startTime)
 
 		semaphore <- struct{}{}
+		wg.Add(1)
 
-		go func(f chan<- *models.Activity, c int) {
+		go func(c int) {
 			defer func() { <-semaphore }()
+			defer wg.Done()
 
 			timeoutCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 			defer cancel()
 
-			started, err := actRepo.Create(timeoutCtx, activity)
+			created, err := actRepo.Create(timeoutCtx, activity)
 			if err != nil {
 				log.Panicln(err.Error())
 			}
 
-			log.Printf("%d - Activity started: %d\n", c, started.ID)
-			f <- started
+			log.Printf("%d - Activity created: %d\n", c, created.ID)
+			counter.Inc()
 
-		}(finishCh, startedCounter)
+		}(index)
 
 		// incr
 		startTime = startTime.Add(time.Hour)
-		startedCounter++
+		index++
 	}
 
-	log.Println("[ ] Total started: ", startedCounter)
+	wg.Wait()
 
 	close(semaphore)
 
-	wg.Wait()
+	log.Println("[ ] Total created: ", counter.Total())
 
 	last := start(categories, time.Now())
-	_, err = actRepo.Create(ctx, last)
+	_, _, err = actRepo.Start(ctx, last)
 	if err != nil {
 		log.Panicln(err)
 	}
 }
 
+// finished creates an activity of one hour finished at t.
+func finished(categories []*models.Category, t time.Time) *models.Activity {
+	activity := start(categories, t)
+	activity.Status = models.Finished
+	activity.SetUpdatedAt(t)
+	activity.SetFinishedAt(t)
+	return activity
+}
+
 func start(categories []*models.Category, t time.Time) *models.Activity {
 	r := random().Intn(len(categories))
 	category := categories[r]
 	activity := &models.Activity{
+		UserID:      types.DefaultUserID,
 		CategoryID:  category.ID,
 		Description: fmt.Sprintf("IT_%s", uuid.NewString()),
 		Status:      models.Started,
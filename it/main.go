@@ -1,10 +1,18 @@
 package main
 
 import (
+	"flag"
+	"github.com/ungame/timetrack/config"
 	"github.com/ungame/timetrack/it/internal"
+	"log"
+	"os"
 	"time"
 )
 
 func main() {
-	internal.Load(time.Now().AddDate(0, -2, 1))
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalln("invalid config:", err.Error())
+	}
+	internal.Load(cfg, time.Now().AddDate(0, -2, 1))
 }
@@ -6,7 +6,7 @@ import (
 )
 
 type Period interface {
-	Range(location *time.Location) (time.Time, time.Time)
+	Range(clock timeext.Clock, location *time.Location) (time.Time, time.Time)
 }
 
 type PeriodType string
@@ -16,6 +16,15 @@ const (
 	Yesterday PeriodType = "yesterday"
 	Weekly    PeriodType = "weekly"
 	Monthly   PeriodType = "monthly"
+
+	ThisWeek    PeriodType = "this_week"
+	LastWeek    PeriodType = "last_week"
+	ThisMonth   PeriodType = "this_month"
+	LastMonth   PeriodType = "last_month"
+	ThisQuarter PeriodType = "this_quarter"
+	LastQuarter PeriodType = "last_quarter"
+	ThisYear    PeriodType = "this_year"
+	LastYear    PeriodType = "last_year"
 )
 
 func (p PeriodType) String() string {
@@ -28,38 +37,95 @@ func (p PeriodType) IsValid() bool {
 	case Yesterday:
 	case Weekly:
 	case Monthly:
+	case ThisWeek, LastWeek:
+	case ThisMonth, LastMonth:
+	case ThisQuarter, LastQuarter:
+	case ThisYear, LastYear:
 	default:
 		return false
 	}
 	return true
 }
 
-func (p PeriodType) Range(location *time.Location) (time.Time, time.Time) {
+// Range returns the bounds of the period at the current time of clock in
+// location. Calendar weeks are ISO weeks, starting on Monday.
+func (p PeriodType) Range(clock timeext.Clock, location *time.Location) (time.Time, time.Time) {
+	return p.rangeAt(clock.Now().In(location), location, time.Monday)
+}
+
+// rangeAt returns the bounds of the period at t. Periods in progress end at
+// t and past ones at the last instant before the next one starts.
+func (p PeriodType) rangeAt(t time.Time, location *time.Location, weekStart time.Weekday) (time.Time, time.Time) {
 	var (
-		now   = time.Now()
 		start time.Time
 		end   time.Time
+		today = timeext.GetStartOfDayFrom(t, location)
 	)
 	switch p {
 	case Today:
-		start = timeext.GetStartOfDay(location)
-		end = now
+		start = today
+		end = t
 
 	case Yesterday:
-		yesterday := now.AddDate(0, 0, -1)
+		yesterday := t.AddDate(0, 0, -1)
 		start = timeext.GetStartOfDayFrom(yesterday, location)
 		end = timeext.GetEndOfDayFrom(yesterday, location)
 
 	case Weekly:
-		sevenDaysAgo := now.AddDate(0, 0, -7)
+		sevenDaysAgo := t.AddDate(0, 0, -7)
 		start = timeext.GetStartOfDayFrom(sevenDaysAgo, location)
-		end = now
+		end = t
 
 	case Monthly:
-		thirtyDaysAgo := now.AddDate(0, 0, -30)
+		thirtyDaysAgo := t.AddDate(0, 0, -30)
 		start = timeext.GetStartOfDayFrom(thirtyDaysAgo, location)
-		end = now
+		end = t
+
+	case ThisWeek, LastWeek:
+		days := (int(t.Weekday()) - int(weekStart) + 7) % 7
+		start = today.AddDate(0, 0, -days)
+		end = t
+		if p == LastWeek {
+			end = start.Add(-time.Nanosecond)
+			start = start.AddDate(0, 0, -7)
+		}
+
+	case ThisMonth, LastMonth:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, location)
+		end = t
+		if p == LastMonth {
+			end = start.Add(-time.Nanosecond)
+			start = start.AddDate(0, -1, 0)
+		}
+
+	case ThisQuarter, LastQuarter:
+		month := (t.Month()-1)/3*3 + 1
+		start = time.Date(t.Year(), month, 1, 0, 0, 0, 0, location)
+		end = t
+		if p == LastQuarter {
+			end = start.Add(-time.Nanosecond)
+			start = start.AddDate(0, -3, 0)
+		}
+
+	case ThisYear, LastYear:
+		start = time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, location)
+		end = t
+		if p == LastYear {
+			end = start.Add(-time.Nanosecond)
+			start = start.AddDate(-1, 0, 0)
+		}
 	}
 
 	return start, end
 }
+
+// WeekPeriod is a period whose calendar weeks start on WeekStart instead of
+// Monday.
+type WeekPeriod struct {
+	PeriodType
+	WeekStart time.Weekday
+}
+
+func (p WeekPeriod) Range(clock timeext.Clock, location *time.Location) (time.Time, time.Time) {
+	return p.rangeAt(clock.Now().In(location), location, p.WeekStart)
+}
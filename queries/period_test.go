@@ -0,0 +1,66 @@
+package queries
+
+import (
+	"github.com/ungame/timetrack/timeext"
+	"testing"
+	"time"
+)
+
+func TestPeriodType_RangeCalendar(t *testing.T) {
+	location := time.FixedZone("UTC-3", -3*60*60)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, location)
+	}
+	endOf := func(year int, month time.Month, day int) time.Time {
+		return date(year, month, day+1).Add(-time.Nanosecond)
+	}
+
+	var (
+		wednesday = time.Date(2024, time.May, 15, 10, 30, 0, 0, location)
+		sunday    = time.Date(2024, time.May, 19, 22, 0, 0, 0, location)
+		january   = time.Date(2024, time.January, 10, 8, 0, 0, 0, location)
+	)
+
+	tests := []struct {
+		now    time.Time
+		period Period
+		start  time.Time
+		end    time.Time
+	}{
+		{now: wednesday, period: Yesterday, start: date(2024, time.May, 14), end: endOf(2024, time.May, 14)},
+		{now: wednesday, period: ThisWeek, start: date(2024, time.May, 13), end: wednesday},
+		{now: wednesday, period: LastWeek, start: date(2024, time.May, 6), end: endOf(2024, time.May, 12)},
+		{now: sunday, period: ThisWeek, start: date(2024, time.May, 13), end: sunday},
+		{now: sunday, period: WeekPeriod{PeriodType: ThisWeek, WeekStart: time.Sunday}, start: date(2024, time.May, 19), end: sunday},
+		{now: wednesday, period: WeekPeriod{PeriodType: LastWeek, WeekStart: time.Sunday}, start: date(2024, time.May, 5), end: endOf(2024, time.May, 11)},
+		{now: wednesday, period: ThisMonth, start: date(2024, time.May, 1), end: wednesday},
+		{now: wednesday, period: LastMonth, start: date(2024, time.April, 1), end: endOf(2024, time.April, 30)},
+		{now: january, period: LastMonth, start: date(2023, time.December, 1), end: endOf(2023, time.December, 31)},
+		{now: wednesday, period: ThisQuarter, start: date(2024, time.April, 1), end: wednesday},
+		{now: wednesday, period: LastQuarter, start: date(2024, time.January, 1), end: endOf(2024, time.March, 31)},
+		{now: january, period: LastQuarter, start: date(2023, time.October, 1), end: endOf(2023, time.December, 31)},
+		{now: wednesday, period: ThisYear, start: date(2024, time.January, 1), end: wednesday},
+		{now: wednesday, period: LastYear, start: date(2023, time.January, 1), end: endOf(2023, time.December, 31)},
+	}
+
+	for _, test := range tests {
+		clock := timeext.NewFrozenClock(test.now.UTC())
+
+		start, end := test.period.Range(clock, location)
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("unexpected range of %v at %s: expected=[%s, %s], got=[%s, %s]",
+				test.period, test.now, test.start, test.end, start, end)
+		}
+	}
+}
+
+func TestPeriodType_RangeStartsInLocation(t *testing.T) {
+	// 01:00 UTC is still the previous day in UTC-3.
+	clock := timeext.NewFrozenClock(time.Date(2024, time.June, 1, 1, 0, 0, 0, time.UTC))
+
+	location := time.FixedZone("UTC-3", -3*60*60)
+	start, _ := ThisMonth.Range(clock, location)
+	if expected := time.Date(2024, time.May, 1, 0, 0, 0, 0, location); !start.Equal(expected) {
+		t.Errorf("unexpected start: expected=%s, got=%s", expected, start)
+	}
+}
@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ungame/timetrack/timeext"
+	"time"
+)
+
+// DateRange is a Period between two arbitrary points in time. Each bound is
+// a date or a timestamp accepted by timeext.Parse. A date covers the whole
+// day, so "from" starts at midnight and "to" ends at the end of the day. An
+// empty "from" means since the beginning and an empty "to" means until now.
+type DateRange struct {
+	from string
+	to   string
+}
+
+func NewDateRange(from, to string) (DateRange, error) {
+	r := DateRange{from: from, to: to}
+	if from == "" && to == "" {
+		return r, errors.New("date range requires from or to")
+	}
+	start, end, err := r.parse(timeext.NewSystemClock(), time.UTC)
+	if err != nil {
+		return r, err
+	}
+	if from != "" && to != "" && end.Before(start) {
+		return r, fmt.Errorf("invalid date range: from %s is after to %s", from, to)
+	}
+	return r, nil
+}
+
+func (r DateRange) Range(clock timeext.Clock, location *time.Location) (time.Time, time.Time) {
+	start, end, _ := r.parse(clock, location)
+	return start, end
+}
+
+func (r DateRange) parse(clock timeext.Clock, location *time.Location) (start time.Time, end time.Time, err error) {
+	end = clock.Now().In(location)
+
+	if r.from != "" {
+		start, err = timeext.Parse(clock, r.from, location)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if r.to != "" {
+		end, err = timeext.Parse(clock, r.to, location)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid to: %w", err)
+		}
+		if isDate(r.to) {
+			end = timeext.GetEndOfDayFrom(end, location)
+		}
+	}
+
+	return start, end, nil
+}
+
+func isDate(value string) bool {
+	_, err := time.Parse(timeext.DateOnlyFormat, value)
+	return err == nil
+}
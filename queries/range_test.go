@@ -0,0 +1,77 @@
+package queries
+
+import (
+	"github.com/ungame/timetrack/timeext"
+	"testing"
+	"time"
+)
+
+func TestDateRange_Range(t *testing.T) {
+	location := time.FixedZone("UTC-3", -3*60*60)
+
+	tests := []struct {
+		from  string
+		to    string
+		start time.Time
+		end   time.Time
+	}{
+		{
+			from:  "2022-10-31",
+			to:    "2022-11-06",
+			start: time.Date(2022, time.October, 31, 0, 0, 0, 0, location),
+			end:   time.Date(2022, time.November, 7, 0, 0, 0, 0, location).Add(-time.Nanosecond),
+		},
+		{
+			from:  "2022-11-01 08:30",
+			to:    "2022-11-01T18:00:00Z",
+			start: time.Date(2022, time.November, 1, 8, 30, 0, 0, location),
+			end:   time.Date(2022, time.November, 1, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			to:  "2022-11-01",
+			end: time.Date(2022, time.November, 2, 0, 0, 0, 0, location).Add(-time.Nanosecond),
+		},
+	}
+
+	for _, test := range tests {
+		period, err := NewDateRange(test.from, test.to)
+		if err != nil {
+			t.Fatalf("unexpected error for from=%q, to=%q: %s", test.from, test.to, err)
+		}
+		start, end := period.Range(timeext.NewFrozenClock(time.Now()), location)
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("unexpected range for from=%q, to=%q: expected=[%s, %s], got=[%s, %s]",
+				test.from, test.to, test.start, test.end, start, end)
+		}
+	}
+}
+
+func TestNewDateRange_Invalid(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{from: "", to: ""},
+		{from: "yesterday", to: ""},
+		{from: "2022-11-01", to: "01/11/2022"},
+		{from: "2022-11-02", to: "2022-11-01"},
+	}
+
+	for _, test := range tests {
+		if _, err := NewDateRange(test.from, test.to); err == nil {
+			t.Errorf("expected error for from=%q, to=%q", test.from, test.to)
+		}
+	}
+}
+
+func TestDateRange_RangeUntilNow(t *testing.T) {
+	period, err := NewDateRange("2022-11-01", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2022, time.November, 3, 15, 0, 0, 0, time.UTC)
+	_, end := period.Range(timeext.NewFrozenClock(now), time.UTC)
+	if !end.Equal(now) {
+		t.Errorf("range without to must end now: expected=%s, got=%s", now, end)
+	}
+}
@@ -0,0 +1,90 @@
+package timeext
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type RoundingMode string
+
+const (
+	RoundNone    RoundingMode = "none"
+	RoundUp      RoundingMode = "up"
+	RoundDown    RoundingMode = "down"
+	RoundNearest RoundingMode = "nearest"
+)
+
+// Rounding rounds durations, such as the ones of billed time entries, to a
+// multiple of Increment.
+type Rounding struct {
+	Mode      RoundingMode
+	Increment time.Duration
+}
+
+// ParseRounding reads a rounding rule as MODE:INCREMENT, e.g. "up:15m" or
+// "nearest:6m", or "none" for no rounding.
+func ParseRounding(value string) (Rounding, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == string(RoundNone) {
+		return Rounding{Mode: RoundNone}, nil
+	}
+
+	mode, increment, ok := strings.Cut(value, ":")
+	if !ok {
+		return Rounding{}, fmt.Errorf("invalid rounding: %q, expected MODE:INCREMENT", value)
+	}
+	rounding := Rounding{Mode: RoundingMode(mode)}
+	switch rounding.Mode {
+	case RoundUp, RoundDown, RoundNearest:
+	default:
+		return Rounding{}, fmt.Errorf("invalid rounding mode: %q", mode)
+	}
+
+	d, err := time.ParseDuration(increment)
+	if err != nil || d < time.Minute {
+		return Rounding{}, fmt.Errorf("invalid rounding increment: %q, must be at least a minute", increment)
+	}
+	rounding.Increment = d
+
+	return rounding, nil
+}
+
+// Round rounds d to a multiple of the increment.
+func (r Rounding) Round(d time.Duration) time.Duration {
+	if r.Increment <= 0 {
+		return d
+	}
+	switch r.Mode {
+	case RoundUp:
+		if rest := d % r.Increment; rest > 0 {
+			return d - rest + r.Increment
+		}
+		return d
+	case RoundDown:
+		return d - d%r.Increment
+	case RoundNearest:
+		return d.Round(r.Increment)
+	}
+	return d
+}
+
+func (r Rounding) String() string {
+	if r.Increment <= 0 || r.Mode == "" || r.Mode == RoundNone {
+		return string(RoundNone)
+	}
+	return fmt.Sprintf("%s:%s", r.Mode, formatIncrement(r.Increment))
+}
+
+// formatIncrement formats d without the zero units of time.Duration.String,
+// e.g. 15m instead of 15m0s.
+func formatIncrement(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package timeext
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRounding_Round(t *testing.T) {
+	tests := []struct {
+		rule     string
+		d        time.Duration
+		expected time.Duration
+	}{
+		{rule: "none", d: 7 * time.Minute, expected: 7 * time.Minute},
+		{rule: "up:15m", d: 0, expected: 0},
+		{rule: "up:15m", d: time.Second, expected: 15 * time.Minute},
+		{rule: "up:15m", d: 30 * time.Minute, expected: 30 * time.Minute},
+		{rule: "UP:15m", d: 31 * time.Minute, expected: 45 * time.Minute},
+		{rule: "down:15m", d: 44 * time.Minute, expected: 30 * time.Minute},
+		{rule: "nearest:6m", d: 8 * time.Minute, expected: 6 * time.Minute},
+		{rule: "nearest:6m", d: 9 * time.Minute, expected: 12 * time.Minute},
+		{rule: "up:1h", d: 61 * time.Minute, expected: 2 * time.Hour},
+	}
+
+	for _, test := range tests {
+		rounding, err := ParseRounding(test.rule)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %s", test.rule, err.Error())
+		}
+		if rounded := rounding.Round(test.d); rounded != test.expected {
+			t.Errorf("unexpected rounding of %s by %s: expected=%s, got=%s", test.d, rounding, test.expected, rounded)
+		}
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":           "none",
+		"none":       "none",
+		" up:15m ":   "up:15m",
+		"down:1h30m": "down:1h30m",
+		"nearest:2h": "nearest:2h",
+	} {
+		rounding, err := ParseRounding(value)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %s", value, err.Error())
+			continue
+		}
+		if rounding.String() != expected {
+			t.Errorf("unexpected rounding of %q: expected=%s, got=%s", value, expected, rounding)
+		}
+	}
+
+	for _, value := range []string{"up", "up:", "ceil:15m", "up:15", "up:30s", "up:-15m"} {
+		if _, err := ParseRounding(value); err == nil {
+			t.Errorf("expected error parsing %q", value)
+		}
+	}
+}
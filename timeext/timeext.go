@@ -1,24 +1,132 @@
 package timeext
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 const (
-	DateOnlyFormat = "2006-01-02"
-	DateTimeFormat = "2006-01-02 15:04:05"
+	DateOnlyFormat   = "2006-01-02"
+	DateTimeFormat   = "2006-01-02 15:04:05"
+	DateMinuteFormat = "2006-01-02 15:04"
+	TimeOnlyFormat   = "15:04"
 )
 
-func GetStartOfDay(location *time.Location) time.Time {
-	return GetStartOfDayFrom(time.Now(), location)
+var layouts = []string{
+	time.RFC3339Nano,
+	DateTimeFormat,
+	DateMinuteFormat,
+	DateOnlyFormat,
+}
+
+func GetStartOfDay(clock Clock, location *time.Location) time.Time {
+	return GetStartOfDayFrom(clock.Now(), location)
 }
 
-func GetEndOfDay(location *time.Location) time.Time {
-	return GetEndOfDayFrom(time.Now(), location)
+func GetEndOfDay(clock Clock, location *time.Location) time.Time {
+	return GetEndOfDayFrom(clock.Now(), location)
 }
 
+// GetStartOfDayFrom returns the start of the day of t as seen in location.
 func GetStartOfDayFrom(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
 }
 
+// GetEndOfDayFrom returns the end of the day of t as seen in location, the
+// last instant before the next day starts, so that it bounds timestamps with
+// fractions of a second.
 func GetEndOfDayFrom(t time.Time, location *time.Location) time.Time {
-	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, location)
+	return GetStartOfDayFrom(t, location).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// Offset is the offset of a location from UTC, in seconds, in effect from an
+// instant on.
+type Offset struct {
+	From    time.Time
+	Seconds int
+}
+
+// Offsets returns the offsets of location in effect within [start, end], the
+// first from start and the others from the second they change at.
+func Offsets(start, end time.Time, location *time.Location) []Offset {
+	var (
+		t          = start.Truncate(time.Second)
+		_, seconds = t.In(location).Zone()
+		offsets    = []Offset{{From: start, Seconds: seconds}}
+	)
+	for t.Before(end) {
+		next := t.Add(12 * time.Hour)
+		if next.After(end) {
+			next = end.Truncate(time.Second)
+		}
+		if _, changed := next.In(location).Zone(); changed != seconds {
+			// the offset changes within (t, next], on a whole second
+			from, to := t, next
+			for to.Sub(from) > time.Second {
+				middle := from.Add((to.Sub(from) / 2).Truncate(time.Second))
+				if _, s := middle.In(location).Zone(); s == seconds {
+					from = middle
+				} else {
+					to = middle
+				}
+			}
+			seconds = changed
+			offsets = append(offsets, Offset{From: to, Seconds: changed})
+		}
+		if !next.After(t) {
+			break
+		}
+		t = next
+	}
+	return offsets
+}
+
+// Parse reads value as RFC 3339, DateTimeFormat, DateMinuteFormat or
+// DateOnlyFormat. A TimeOnlyFormat value refers to the current day of clock.
+// Values without offset are interpreted in location.
+func Parse(clock Clock, value string, location *time.Location) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation(TimeOnlyFormat, value, location); err == nil {
+		now := clock.Now().In(location)
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, location), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", value)
+}
+
+// FormatHoursMinutes formats d, rounded to the minute, as hh:mm. Hours are
+// not limited to a day.
+func FormatHoursMinutes(d time.Duration) string {
+	minutes := int64(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ParseWeekday reads a weekday by its English name, e.g. "monday" or "Sun".
+func ParseWeekday(value string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday: %q", value)
+}
+
+// LoadLocation returns the location of the IANA timezone name, or fallback
+// when name is empty.
+func LoadLocation(name string, fallback *time.Location) (*time.Location, error) {
+	if name == "" {
+		return fallback, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %q", name)
+	}
+	return location, nil
 }
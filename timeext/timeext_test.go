@@ -0,0 +1,74 @@
+package timeext
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// still October 31 in Sao Paulo
+	clock := NewFrozenClock(time.Date(2022, time.November, 1, 1, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{value: "2022-11-01T10:30:00Z", expected: time.Date(2022, time.November, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2022-11-01T10:30:00.5+01:00", expected: time.Date(2022, time.November, 1, 9, 30, 0, 5e8, time.UTC)},
+		{value: "2022-11-01 10:30:15", expected: time.Date(2022, time.November, 1, 10, 30, 15, 0, location)},
+		{value: "2022-11-01 10:30", expected: time.Date(2022, time.November, 1, 10, 30, 0, 0, location)},
+		{value: "2022-11-01", expected: time.Date(2022, time.November, 1, 0, 0, 0, 0, location)},
+		{value: "10:30", expected: time.Date(2022, time.October, 31, 10, 30, 0, 0, location)},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(clock, test.value, location)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %s", test.value, err.Error())
+			continue
+		}
+		if !got.Equal(test.expected) {
+			t.Errorf("unexpected time of %q: expected=%s, got=%s", test.value, test.expected, got)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "2022-13-01", "25:00", "01/11/2022 10:30"} {
+		if _, err := Parse(clock, value, location); err == nil {
+			t.Errorf("expected error parsing %q", value)
+		}
+	}
+}
+
+func TestOffsets(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		start = time.Date(2022, time.March, 1, 0, 0, 0, 0, location)
+		end   = time.Date(2022, time.December, 31, 23, 59, 59, 999999999, location)
+	)
+
+	expected := []Offset{
+		{From: start, Seconds: -5 * 3600},
+		{From: time.Date(2022, time.March, 13, 7, 0, 0, 0, time.UTC), Seconds: -4 * 3600},
+		{From: time.Date(2022, time.November, 6, 6, 0, 0, 0, time.UTC), Seconds: -5 * 3600},
+	}
+	offsets := Offsets(start, end, location)
+	if len(offsets) != len(expected) {
+		t.Fatalf("expected %d offsets, got %v", len(expected), offsets)
+	}
+	for i, offset := range offsets {
+		if !offset.From.Equal(expected[i].From) || offset.Seconds != expected[i].Seconds {
+			t.Errorf("unexpected offset %d: expected=%v, got=%v", i, expected[i], offset)
+		}
+	}
+
+	if offsets = Offsets(start, end, time.UTC); len(offsets) != 1 || offsets[0].Seconds != 0 {
+		t.Errorf("expected a single offset in UTC, got %v", offsets)
+	}
+}
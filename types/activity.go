@@ -4,12 +4,45 @@ import (
 	"time"
 )
 
+// Activity is a tracked activity. Rates are in cents per hour, and an
+// activity without HourlyRate is billed at the rate of its category. An
+// activity without ProjectID belongs to no project, and updates with a
+// ProjectID of 0 remove it from its project.
 type Activity struct {
-	ID          int64      `json:"id"`
-	CategoryID  int64      `json:"category_id"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	StartedAt   *time.Time `json:"started_at"`
-	UpdatedAt   *time.Time `json:"updated_at"`
-	FinishedAt  *time.Time `json:"finished_at"`
+	ID          int64               `json:"id"`
+	UserID      int64               `json:"user_id"`
+	CategoryID  int64               `json:"category_id"`
+	ProjectID   *int64              `json:"project_id,omitempty"`
+	Billable    *bool               `json:"billable,omitempty"`
+	HourlyRate  *int64              `json:"hourly_rate,omitempty"`
+	Description string              `json:"description"`
+	Status      string              `json:"status"`
+	StartedAt   *time.Time          `json:"started_at"`
+	UpdatedAt   *time.Time          `json:"updated_at"`
+	FinishedAt  *time.Time          `json:"finished_at"`
+	Duration    int64               `json:"duration"`
+	Tags        []string            `json:"tags,omitempty"`
+	Intervals   []*ActivityInterval `json:"intervals,omitempty"`
+}
+
+// ActivityRecord is an activity with its category name, as exported to and
+// imported from files.
+type ActivityRecord struct {
+	Activity
+	Category string `json:"category"`
+}
+
+type ActivityInterval struct {
+	ID         int64      `json:"id"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type ActivityChange struct {
+	ID         int64      `json:"id"`
+	ActivityID int64      `json:"activity_id"`
+	Field      string     `json:"field"`
+	OldValue   *string    `json:"old_value"`
+	NewValue   *string    `json:"new_value"`
+	ChangedAt  *time.Time `json:"changed_at"`
 }
@@ -1,13 +1,19 @@
 package types
 
-type Error struct {
-	Err string `json:"error"`
+// Problem is an error response of the API, the problem details of RFC 7807
+// extended with a stable code, e.g. activity_not_found, and the fields of
+// the request at fault.
+type Problem struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Status   int             `json:"status"`
+	Detail   string          `json:"detail,omitempty"`
+	Instance string          `json:"instance,omitempty"`
+	Code     string          `json:"code"`
+	Fields   []*ProblemField `json:"fields,omitempty"`
 }
 
-func NewError(e error) *Error {
-	err := Error{"error"}
-	if e != nil {
-		err.Err = e.Error()
-	}
-	return &err
+type ProblemField struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
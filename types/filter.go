@@ -2,6 +2,26 @@ package types
 
 type PeriodFilter struct {
 	PeriodName string
+	From       string
+	To         string
+	Timezone   string
+	WeekStart  string
 	OrderBy    string
 	Limit      int
+	ActivityFilter
+}
+
+// ActivityFilter narrows activities down to the ones with a tag, of a project
+// or of the projects of a client. Zero fields do not filter.
+type ActivityFilter struct {
+	Tag       string
+	ProjectID int64
+	ClientID  int64
+}
+
+// BillingFilter is a period filter with the rounding rule of the durations
+// of billed activities, as read by timeext.ParseRounding.
+type BillingFilter struct {
+	PeriodFilter
+	Rounding string
 }
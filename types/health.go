@@ -0,0 +1,10 @@
+package types
+
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+type Health struct {
+	Status string `json:"status"`
+}
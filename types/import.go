@@ -0,0 +1,27 @@
+package types
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusFailed   = "failed"
+)
+
+// ImportResult reports what happened to each row of an import. On a dry run
+// nothing is written and imported rows are the ones that would be imported.
+type ImportResult struct {
+	DryRun   bool         `json:"dry_run"`
+	Imported int          `json:"imported"`
+	Skipped  int          `json:"skipped"`
+	Failed   int          `json:"failed"`
+	Rows     []*ImportRow `json:"rows"`
+}
+
+type ImportRow struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     int64  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,8 @@
+package types
+
+// Page selects a page of a keyset pagination: at most Size items after the
+// one with id AfterID, or from the first one when it is zero.
+type Page struct {
+	AfterID int64
+	Size    int
+}
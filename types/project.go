@@ -0,0 +1,22 @@
+package types
+
+import (
+	"time"
+)
+
+type Client struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type Project struct {
+	ID          int64      `json:"id"`
+	ClientID    int64      `json:"client_id,omitempty"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
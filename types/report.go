@@ -0,0 +1,61 @@
+package types
+
+import (
+	"time"
+)
+
+type Summary struct {
+	From       *time.Time         `json:"from"`
+	To         *time.Time         `json:"to"`
+	Count      int64              `json:"count"`
+	Duration   int64              `json:"duration"`
+	Categories []*CategorySummary `json:"categories"`
+	Tags       []*TagSummary      `json:"tags"`
+	Days       []*DaySummary      `json:"days"`
+}
+
+type CategorySummary struct {
+	CategoryID int64   `json:"category_id"`
+	Category   string  `json:"category"`
+	Count      int64   `json:"count"`
+	Duration   int64   `json:"duration"`
+	Share      float64 `json:"share"`
+}
+
+// TagSummary is the time tracked in activities with a tag. Activities may
+// have several tags, so tags do not add up to the total.
+type TagSummary struct {
+	Tag      string `json:"tag"`
+	Count    int64  `json:"count"`
+	Duration int64  `json:"duration"`
+}
+
+type DaySummary struct {
+	Date       string             `json:"date"`
+	Count      int64              `json:"count"`
+	Duration   int64              `json:"duration"`
+	Categories []*CategorySummary `json:"categories"`
+}
+
+// Billing is the billable time and amount of the finished billable
+// activities of a period. The duration of each activity is rounded before it
+// is billed at its hourly rate. Amounts are in cents, like rates.
+type Billing struct {
+	From       *time.Time         `json:"from"`
+	To         *time.Time         `json:"to"`
+	Rounding   string             `json:"rounding"`
+	Count      int64              `json:"count"`
+	Duration   int64              `json:"duration"`
+	Hours      float64            `json:"hours"`
+	Amount     int64              `json:"amount"`
+	Categories []*CategoryBilling `json:"categories"`
+}
+
+type CategoryBilling struct {
+	CategoryID int64   `json:"category_id"`
+	Category   string  `json:"category"`
+	Count      int64   `json:"count"`
+	Duration   int64   `json:"duration"`
+	Hours      float64 `json:"hours"`
+	Amount     int64   `json:"amount"`
+}
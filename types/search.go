@@ -0,0 +1,17 @@
+package types
+
+// SearchFilter is a full-text search of activities, only within the period
+// of the filter if it sets one.
+type SearchFilter struct {
+	Query string
+	PeriodFilter
+}
+
+// SearchResult is an activity found by a search, from the best match, with
+// the matching words of its description highlighted in <mark> elements.
+type SearchResult struct {
+	Activity
+	Category  string  `json:"category"`
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
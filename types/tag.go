@@ -0,0 +1,43 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxTagLength is the maximum number of characters of a tag.
+const MaxTagLength = 50
+
+var (
+	tagPattern     = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+	hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+)
+
+// NormalizeTag returns the tag in lower case and without the leading #. It
+// reports false when the tag is empty, too long or has characters other than
+// letters, digits, - and _.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if len([]rune(tag)) > MaxTagLength || !tagPattern.MatchString(tag) {
+		return tag, false
+	}
+	return tag, true
+}
+
+// HashtagsOf returns the tags written as #hashtags in text, normalized and
+// without repetitions, e.g. "deploy #release-42 #OnCall" has the tags
+// release-42 and oncall.
+func HashtagsOf(text string) []string {
+	var (
+		tags = make([]string, 0)
+		seen = make(map[string]bool)
+	)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag, ok := NormalizeTag(match[1])
+		if ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
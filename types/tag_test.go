@@ -0,0 +1,26 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtagsOf(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "", expected: []string{}},
+		{text: "fix login", expected: []string{}},
+		{text: "#oncall", expected: []string{"oncall"}},
+		{text: "deploy #release-42 #OnCall", expected: []string{"release-42", "oncall"}},
+		{text: "#a #b #A, issue#12 # c", expected: []string{"a", "b"}},
+		{text: "revisão #código_novo", expected: []string{"código_novo"}},
+	}
+
+	for _, test := range tests {
+		if tags := HashtagsOf(test.text); !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("unexpected tags of %q: expected=%v, got=%v", test.text, test.expected, tags)
+		}
+	}
+}
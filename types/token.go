@@ -0,0 +1,33 @@
+package types
+
+import (
+	"net/http"
+	"time"
+)
+
+// Scopes of the API tokens. Read tokens may only send GET and HEAD requests,
+// while write tokens may send any request.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Token is an API token of a user. Its secret, sent as a bearer token in the
+// Authorization header, is only known when the token is created.
+type Token struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	Secret    string     `json:"token,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Allows reports whether the token may send requests of the method.
+func (t *Token) Allows(method string) bool {
+	if t.Scope == ScopeWrite {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead
+}
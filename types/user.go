@@ -0,0 +1,16 @@
+package types
+
+import (
+	"time"
+)
+
+// DefaultUserID is the user of the requests that do not name one, which owns
+// the activities recorded before users existed.
+const DefaultUserID int64 = 1
+
+type User struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}